@@ -1,7 +1,25 @@
 package main
 
-import "scaffold/internal/app"
+import (
+	"context"
+	"fmt"
+	"os"
+	"scaffold/internal/app"
+)
 
 func main() {
+	if err := app.Bootstrap(context.Background()); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(app.ExitCode(err))
+	}
+
+	// 子命令执行完直接退出
+	if handled, err := app.RunCommand(os.Args[1:]); handled {
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(app.ExitUnknown)
+		}
+		return
+	}
 	app.Start()
 }
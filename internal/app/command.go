@@ -0,0 +1,176 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"scaffold/internal/config"
+	"scaffold/internal/router"
+	"scaffold/pkg/auth"
+	"scaffold/pkg/tlsutil"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// command 命令行子命令
+type command struct {
+	usage string
+	run   func(args []string) error
+}
+
+var commands = map[string]command{
+	"cert":    {usage: "cert generate [-host localhost,127.0.0.1] [-days 365] [-cert cert.pem] [-key key.pem] [-force]", run: runCert},
+	"profile": {usage: "profile [-addr host:port] [-token token] [-seconds 30] [-o cpu.pprof]", run: runProfile},
+	"apikey":  {usage: "apikey generate [-name name] [-scopes a,b]", run: runAPIKey},
+}
+
+// RunCommand 执行子命令，args 为去掉程序名后的参数。
+// 第一个参数不是已知子命令时返回 false，由调用方继续正常启动。
+func RunCommand(args []string) (bool, error) {
+	if len(args) == 0 {
+		return false, nil
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		return false, nil
+	}
+	if err := cmd.run(args[1:]); err != nil {
+		return true, fmt.Errorf("%s: %w\nusage: %s %s", args[0], err, os.Args[0], cmd.usage)
+	}
+	return true, nil
+}
+
+// runCert 生成本地开发用的自签名证书，默认写入当前目录。
+// 不使用配置中的证书路径，已存在的文件需指定 -force 才会覆盖，避免误替换正式证书。
+func runCert(args []string) error {
+	if len(args) == 0 || args[0] != "generate" {
+		return errors.New("unknown cert command")
+	}
+
+	fs := flag.NewFlagSet("cert generate", flag.ContinueOnError)
+	hosts := fs.String("host", "localhost,127.0.0.1,::1", "comma-separated hostnames and IPs")
+	days := fs.Int("days", 365, "validity in days")
+	certFile := fs.String("cert", "cert.pem", "certificate output file")
+	keyFile := fs.String("key", "key.pem", "private key output file")
+	force := fs.Bool("force", false, "overwrite existing files")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if !*force {
+		for _, f := range []string{*certFile, *keyFile} {
+			if _, err := os.Stat(f); err == nil {
+				return fmt.Errorf("%s already exists, use -force to overwrite", f)
+			} else if !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+		}
+	}
+
+	var hostList []string
+	for _, h := range strings.Split(*hosts, ",") {
+		if h = strings.TrimSpace(h); h != "" {
+			hostList = append(hostList, h)
+		}
+	}
+
+	validFor := time.Duration(*days) * 24 * time.Hour
+	if err := tlsutil.WriteSelfSigned(*certFile, *keyFile, hostList, validFor); err != nil {
+		return err
+	}
+	fmt.Printf("certificate written to %s, private key written to %s\n", *certFile, *keyFile)
+	return nil
+}
+
+// runAPIKey 生成随机 API Key，输出明文（只显示一次）和写入配置的条目
+func runAPIKey(args []string) error {
+	if len(args) == 0 || args[0] != "generate" {
+		return errors.New("unknown apikey command")
+	}
+
+	fs := flag.NewFlagSet("apikey generate", flag.ContinueOnError)
+	name := fs.String("name", "default", "key name, used as the caller identity")
+	scopes := fs.String("scopes", "", "comma-separated scopes")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	entry := config.APIKeyConfig{Name: *name, Scopes: []string{}}
+	for _, s := range strings.Split(*scopes, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			entry.Scopes = append(entry.Scopes, s)
+		}
+	}
+	key := auth.GenerateAPIKey()
+	entry.Hash = auth.HashAPIKey(key)
+
+	snippet, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Printf("api key (shown only once): %s\nadd to auth.api_keys in config.json:\n%s\n", key, snippet)
+	return nil
+}
+
+// runProfile 通过运维接口从运行中的实例采集 CPU profile 并写入文件，默认使用配置中的运维地址和令牌
+func runProfile(args []string) error {
+	cfg := config.GetConfig()
+	fs := flag.NewFlagSet("profile", flag.ContinueOnError)
+	addr := fs.String("addr", router.AdminAddr(cfg.AdminListen), "admin listener address")
+	token := fs.String("token", cfg.DebugToken, "debug token")
+	seconds := fs.Int("seconds", 30, "profile duration in seconds")
+	out := fs.String("o", "cpu.pprof", "output file")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *addr == "" {
+		return errors.New("admin listener not configured, use -addr")
+	}
+	if *seconds <= 0 {
+		return errors.New("seconds must be positive")
+	}
+
+	u := url.URL{
+		Scheme:   "http",
+		Host:     *addr,
+		Path:     "/debug/pprof/profile",
+		RawQuery: "seconds=" + strconv.Itoa(*seconds),
+	}
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+*token)
+
+	fmt.Printf("capturing %ds CPU profile from %s ...\n", *seconds, *addr)
+	client := &http.Client{Timeout: time.Duration(*seconds)*time.Second + 30*time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("unexpected status %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	f, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	n, err := io.Copy(f, resp.Body)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(*out)
+		return err
+	}
+	fmt.Printf("profile written to %s (%d bytes), inspect with: go tool pprof %s\n", *out, n, *out)
+	return nil
+}
@@ -24,7 +24,7 @@ func (p *program) Start(s service.Service) error {
 
 func (p *program) Stop(s service.Service) error {
 	// Stop should not block. Return with a few seconds.
-	return nil
+	return shutdown()
 }
 
 func getService() service.Service {
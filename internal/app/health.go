@@ -0,0 +1,29 @@
+package app
+
+import (
+	"context"
+	"scaffold/internal/config"
+	"scaffold/pkg/health"
+	"scaffold/pkg/logger"
+	"time"
+)
+
+// minFreeDisk 数据目录所在磁盘的最低可用空间
+const minFreeDisk = 100 << 20 // 100MB
+
+// registerHealthChecks 注册内置的就绪检查
+func registerHealthChecks() {
+	dataPath := config.GetConfig().DataPath
+
+	health.Register("data_path", health.Writable(dataPath))
+	health.Register("log_writer", func(ctx context.Context) error {
+		return logger.Healthy()
+	})
+	health.RegisterInfo("log_output", func() any {
+		if logger.FileLogging() {
+			return "file"
+		}
+		return "stderr"
+	})
+	health.Register("disk_free", health.DiskFree(dataPath, minFreeDisk), health.WithCacheTTL(30*time.Second))
+}
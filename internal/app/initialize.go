@@ -1,15 +1,89 @@
 package app
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
 	"scaffold/internal/config"
 	"scaffold/pkg/logger"
 )
 
-func InitLog() {
-	// 初始化日志
-	logger.InitMyLog()
+// 启动失败时的进程退出码，按失败类别区分
+const (
+	ExitOK       = 0
+	ExitUnknown  = 1
+	ExitConfig   = 2
+	ExitDataPath = 3
+	ExitCanceled = 4
+)
+
+// BootstrapError 描述启动过程中某个阶段的失败
+type BootstrapError struct {
+	Stage string
+	Code  int
+	Err   error
+}
+
+func (e *BootstrapError) Error() string {
+	return fmt.Sprintf("bootstrap %s: %v", e.Stage, e.Err)
+}
+
+func (e *BootstrapError) Unwrap() error {
+	return e.Err
+}
+
+// ExitCode 返回错误对应的进程退出码
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	var be *BootstrapError
+	if errors.As(err, &be) {
+		return be.Code
+	}
+	return ExitUnknown
+}
+
+// Bootstrap 按顺序初始化日志、配置和数据目录，任一阶段失败都会返回 *BootstrapError
+func Bootstrap(ctx context.Context) error {
+	stages := []struct {
+		name string
+		code int
+		fn   func() error
+	}{
+		{"log", ExitUnknown, initLog},
+		{"config", ExitConfig, config.InitConfig},
+		{"data_path", ExitDataPath, initDataPath},
+	}
+
+	for _, s := range stages {
+		if err := ctx.Err(); err != nil {
+			return &BootstrapError{Stage: s.name, Code: ExitCanceled, Err: err}
+		}
+		if err := s.fn(); err != nil {
+			return &BootstrapError{Stage: s.name, Code: s.code, Err: err}
+		}
+		slog.Debug("bootstrap stage done", "stage", s.name)
+	}
+	return nil
+}
+
+// initLog 初始化文件日志，失败时退回到标准错误输出，不中断启动
+func initLog() error {
+	if err := logger.InitMyLog(); err != nil {
+		logger.InitStderrLog()
+		slog.Warn("file logging unavailable, fallback to stderr", "error", err)
+	}
+	return nil
 }
 
-func InitConfig() {
-	config.InitConfig()
+// initDataPath 确保配置的数据目录存在
+func initDataPath() error {
+	dataPath := config.GetConfig().DataPath
+	if dataPath == "" {
+		return nil
+	}
+	return os.MkdirAll(dataPath, 0755)
 }
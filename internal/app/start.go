@@ -1,22 +1,30 @@
 package app
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
+	"os"
+	"os/signal"
 	"scaffold/internal/config"
 	"scaffold/internal/router"
 	"scaffold/pkg/common"
 	"scaffold/pkg/common/util"
+	"syscall"
+	"time"
 )
 
-func init() {
-	InitLog()
-	InitConfig()
-}
-
+// Start 启动服务，调用前需先执行 Bootstrap
 func Start() {
 	slog.Info(fmt.Sprintf("Start %s version %s", config.GetConfig().Service.Name, common.Version))
 	if util.IsRunInDocker() {
+		// 容器中直接运行，收到终止信号时优雅关闭
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		go func() {
+			<-ctx.Done()
+			shutdown()
+		}()
 		run()
 	} else {
 		startDaemon()
@@ -24,5 +32,20 @@ func Start() {
 }
 
 func run() {
+	registerHealthChecks()
+	watchUpgradeSignal()
 	router.ListenAndServe()
 }
+
+// shutdownTimeout 优雅关闭时等待进行中请求的最长时间
+const shutdownTimeout = 5 * time.Second
+
+func shutdown() error {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := router.Shutdown(ctx); err != nil {
+		slog.Error("Server shutdown failed", "error", err)
+		return err
+	}
+	return nil
+}
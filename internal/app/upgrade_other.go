@@ -0,0 +1,6 @@
+//go:build !unix
+
+package app
+
+// watchUpgradeSignal 非 Unix 平台不支持平滑升级
+func watchUpgradeSignal() {}
@@ -0,0 +1,37 @@
+//go:build unix
+
+package app
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"scaffold/internal/router"
+	"syscall"
+	"time"
+)
+
+// upgradeTimeout 等待新进程就绪的最长时间
+const upgradeTimeout = 30 * time.Second
+
+// watchUpgradeSignal 收到 SIGUSR2 时平滑升级：新进程接管监听器后，当前进程排空请求并退出
+func watchUpgradeSignal() {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGUSR2)
+	go func() {
+		for range ch {
+			slog.Info("upgrade requested")
+			ctx, cancel := context.WithTimeout(context.Background(), upgradeTimeout)
+			err := router.Upgrade(ctx)
+			cancel()
+			if err != nil {
+				slog.Error("upgrade failed, keep serving", "error", err)
+				continue
+			}
+			shutdown()
+			slog.Info("upgrade finished, old process exits")
+			os.Exit(0)
+		}
+	}()
+}
@@ -20,9 +20,24 @@ var (
 
 // 导出的配置结构
 type Config struct {
-	Service    ServiceConfig `json:"service"`
-	DataPath   string        `json:"data_path"`
-	ListenPort string        `json:"listen_port"`
+	Service     ServiceConfig   `json:"service"`
+	DataPath    string          `json:"data_path"`
+	ListenPort  string          `json:"listen_port"`
+	UnixSocket  string          `json:"unix_socket"`      // Unix 域套接字路径，可与 listen_port 同时使用
+	SocketMode  string          `json:"unix_socket_mode"` // 套接字文件权限，八进制，如 "0660"
+	H2C         bool            `json:"h2c"`              // 明文监听器是否接受 HTTP/2（h2c）
+	AdminListen string          `json:"admin_listen"`     // 运维接口监听地址，省略主机时只绑定 127.0.0.1，为空时不启用
+	DebugToken  string          `json:"debug_token"`      // 运维接口 /debug/ 下 pprof 等调试接口的访问令牌，为空时不启用
+	WebDir      string          `json:"web_dir"`          // 前端资源目录，为空时使用内嵌资源
+	TLS         TLSConfig       `json:"tls"`
+	CORS        CORSConfig      `json:"cors"`
+	Auth        AuthConfig      `json:"auth"`
+	RateLimit   RateLimitConfig `json:"rate_limit"`
+	Compress    CompressConfig  `json:"compress"`
+
+	// TrustedProxies 可信反向代理的地址或网段，只有来自这些地址的请求才采信 X-Forwarded-For，
+	// 日志中的客户端 IP 和按 IP 限流都以此为准
+	TrustedProxies []string `json:"trusted_proxies"`
 }
 
 type ServiceConfig struct {
@@ -31,6 +46,82 @@ type ServiceConfig struct {
 	Description string `json:"description"`
 }
 
+// TLSConfig HTTPS 配置，cert_file 和 key_file 均不为空时启用
+type TLSConfig struct {
+	CertFile     string `json:"cert_file"`
+	KeyFile      string `json:"key_file"`
+	MinVersion   string `json:"min_version"`   // "1.2" 或 "1.3"，默认 1.2
+	CipherPolicy string `json:"cipher_policy"` // default、intermediate、modern
+	RedirectHTTP string `json:"redirect_http"` // 非空时在该地址监听 HTTP 并跳转到 HTTPS，如 ":80"
+	ClientCA     string `json:"client_ca"`     // 校验客户端证书的 CA 文件，为空时不请求客户端证书
+	ClientAuth   string `json:"client_auth"`   // 客户端证书校验模式：required 或 optional，默认 required
+}
+
+// CORSConfig 主服务默认跨域策略，allowed_origins 为空时不允许跨域请求
+type CORSConfig struct {
+	AllowedOrigins   []string `json:"allowed_origins"` // 精确值、"https://*.example.com" 通配子域名、"regex:" 前缀的正则或 "*"
+	AllowedMethods   []string `json:"allowed_methods"`
+	AllowedHeaders   []string `json:"allowed_headers"`
+	ExposedHeaders   []string `json:"exposed_headers"`
+	AllowCredentials bool     `json:"allow_credentials"`
+	MaxAge           int      `json:"max_age"` // 预检结果缓存秒数
+}
+
+// AuthConfig 接口认证配置，api_keys 与 jwt 均未配置时不启用认证
+type AuthConfig struct {
+	APIKeys []APIKeyConfig `json:"api_keys"`
+	JWT     JWTConfig      `json:"jwt"`
+}
+
+// APIKeyConfig 静态 API Key，只保存哈希，可用 apikey generate 生成
+type APIKeyConfig struct {
+	Name   string   `json:"name"`
+	Hash   string   `json:"hash"` // "sha256:<hex>"
+	Scopes []string `json:"scopes"`
+}
+
+// JWTConfig Bearer 令牌校验配置，hmac_secret 与 jwks_file 均为空时不启用
+type JWTConfig struct {
+	Issuer     string   `json:"issuer"`
+	Audience   []string `json:"audience"`
+	HMACSecret string   `json:"hmac_secret"` // HS256 密钥，至少 32 字节
+	JWKSFile   string   `json:"jwks_file"`   // RS256/EdDSA 公钥，文件更新后自动重新加载
+	Leeway     int      `json:"leeway"`      // 允许的时钟偏差秒数，默认 60
+	QueryParam string   `json:"query_param"` // 允许从该查询参数读取令牌，用于 WebSocket 和 SSE
+}
+
+// Enabled 是否配置了认证
+func (a AuthConfig) Enabled() bool {
+	return len(a.APIKeys) > 0 || a.JWT.Enabled()
+}
+
+// Enabled 是否启用 JWT 认证
+func (j JWTConfig) Enabled() bool {
+	return j.HMACSecret != "" || j.JWKSFile != ""
+}
+
+// RateLimitConfig 接口限流配置，requests 为 0 时不限流
+type RateLimitConfig struct {
+	Requests int    `json:"requests"` // 每个窗口补充的请求数
+	Window   int    `json:"window"`   // 窗口秒数，默认 1
+	Burst    int    `json:"burst"`    // 允许的突发请求数，默认等于 requests
+	Key      string `json:"key"`      // 限流维度：ip（默认）、identity 或 route
+	MaxKeys  int    `json:"max_keys"` // 最多保留的限流桶数，默认 100000
+}
+
+// CompressConfig 响应压缩配置，默认启用
+type CompressConfig struct {
+	Disabled     bool     `json:"disabled"`
+	MinSize      int      `json:"min_size"`      // 小于该字节数的响应不压缩，默认 1024
+	Level        int      `json:"level"`         // 压缩级别 1-9，0 为默认级别
+	ContentTypes []string `json:"content_types"` // 允许压缩的媒体类型，如 "text/*"，为空时使用默认列表
+}
+
+// Enabled 是否启用 HTTPS
+func (t TLSConfig) Enabled() bool {
+	return t.CertFile != "" && t.KeyFile != ""
+}
+
 // InitConfig 初始化配置，只会执行一次
 func InitConfig() error {
 	var err error
@@ -1,8 +1,13 @@
 package api
 
-import "net/http"
+import "context"
 
-func IndexHandler(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-	w.Write([]byte(`{"msg" : "ok"}`))
+// IndexResponse 服务状态响应
+type IndexResponse struct {
+	Msg string `json:"msg"`
+}
+
+// Index 返回服务状态
+func Index(ctx context.Context, _ struct{}) (IndexResponse, error) {
+	return IndexResponse{Msg: "ok"}, nil
 }
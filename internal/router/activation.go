@@ -0,0 +1,171 @@
+package router
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// 监听器继承相关的环境变量。LISTEN_* 遵循 systemd socket activation 约定，
+// 平滑升级时由父进程设置 envUpgradePPID 代替 LISTEN_PID（fork 前无法得知子进程 PID）。
+const (
+	envListenPID     = "LISTEN_PID"
+	envListenFDs     = "LISTEN_FDS"
+	envListenFDNames = "LISTEN_FDNAMES"
+	envUpgradePPID   = "SCAFFOLD_UPGRADE_PPID"
+	envReadyFD       = "SCAFFOLD_READY_FD"
+
+	listenFDsStart = 3
+)
+
+// listenerNames 本程序使用的监听器名称。systemd 未配置 FileDescriptorName= 时名称为 socket 单元名，
+// 这类名称不属于本程序，按地址匹配。
+var listenerNames = map[string]bool{"http": true, "https": true, "unix": true, "admin": true, "redirect": true}
+
+type inheritedListener struct {
+	name string
+	ln   net.Listener
+}
+
+var (
+	inheritOnce sync.Once
+	inheritMu   sync.Mutex
+	inherited   []*inheritedListener
+)
+
+// loadInherited 读取继承的监听器，只执行一次并清理环境变量，避免传给后续子进程
+func loadInherited() {
+	inheritOnce.Do(func() {
+		defer func() {
+			os.Unsetenv(envListenPID)
+			os.Unsetenv(envListenFDs)
+			os.Unsetenv(envListenFDNames)
+			os.Unsetenv(envUpgradePPID)
+		}()
+
+		if !inheritedForUs() {
+			return
+		}
+		n, err := strconv.Atoi(os.Getenv(envListenFDs))
+		if err != nil || n <= 0 {
+			return
+		}
+		names := strings.Split(os.Getenv(envListenFDNames), ":")
+
+		for i := range n {
+			fd := listenFDsStart + i
+			f := os.NewFile(uintptr(fd), fmt.Sprintf("listen-fd-%d", fd))
+			ln, err := net.FileListener(f)
+			f.Close()
+			if err != nil {
+				slog.Warn("ignore inherited fd", "fd", fd, "error", err)
+				continue
+			}
+			il := &inheritedListener{ln: ln}
+			if i < len(names) {
+				il.name = names[i]
+			}
+			slog.Info("inherited listener", "fd", fd, "name", il.name, "addr", ln.Addr().String())
+			inherited = append(inherited, il)
+		}
+	})
+}
+
+// inheritedForUs 判断继承的描述符是否属于当前进程
+func inheritedForUs() bool {
+	if pid, err := strconv.Atoi(os.Getenv(envListenPID)); err == nil && pid == os.Getpid() {
+		return true
+	}
+	if ppid, err := strconv.Atoi(os.Getenv(envUpgradePPID)); err == nil && ppid == os.Getppid() {
+		return true
+	}
+	return false
+}
+
+// takeInherited 按名称或地址取出一个继承的监听器，名称不是本程序监听器名称时按地址匹配
+func takeInherited(name, network, addr string) net.Listener {
+	loadInherited()
+
+	inheritMu.Lock()
+	defer inheritMu.Unlock()
+
+	match := func(pred func(*inheritedListener) bool) net.Listener {
+		for i, il := range inherited {
+			if pred(il) {
+				inherited = append(inherited[:i], inherited[i+1:]...)
+				return il.ln
+			}
+		}
+		return nil
+	}
+
+	if ln := match(func(il *inheritedListener) bool { return il.name == name }); ln != nil {
+		return ln
+	}
+	return match(func(il *inheritedListener) bool {
+		return !listenerNames[il.name] && sameAddr(il.ln.Addr(), network, addr)
+	})
+}
+
+// closeUnusedInherited 关闭没有被任何配置使用的继承监听器
+func closeUnusedInherited() {
+	inheritMu.Lock()
+	defer inheritMu.Unlock()
+	for _, il := range inherited {
+		slog.Warn("close unused inherited listener", "name", il.name, "addr", il.ln.Addr().String())
+		il.ln.Close()
+	}
+	inherited = nil
+}
+
+// sameAddr 比较监听地址，配置中省略主机时只比较端口
+func sameAddr(a net.Addr, network, addr string) bool {
+	if network == "unix" {
+		return a.Network() == "unix" && a.String() == addr
+	}
+	ta, ok := a.(*net.TCPAddr)
+	if !ok {
+		return false
+	}
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil || strconv.Itoa(ta.Port) != port {
+		return false
+	}
+	if host == "" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.Equal(ta.IP)
+}
+
+// listen 优先使用继承的监听器，否则新建
+func listen(name, network, addr string) (net.Listener, bool, error) {
+	if ln := takeInherited(name, network, addr); ln != nil {
+		return ln, true, nil
+	}
+	ln, err := net.Listen(network, addr)
+	return ln, false, err
+}
+
+// notifyReady 平滑升级时通知父进程：新进程已开始提供服务
+func notifyReady() {
+	v := os.Getenv(envReadyFD)
+	if v == "" {
+		return
+	}
+	os.Unsetenv(envReadyFD)
+
+	fd, err := strconv.Atoi(v)
+	if err != nil {
+		return
+	}
+	f := os.NewFile(uintptr(fd), "upgrade-ready")
+	if _, err := f.Write([]byte{1}); err != nil {
+		slog.Warn("notify upgrade parent failed", "error", err)
+	}
+	f.Close()
+}
@@ -0,0 +1,96 @@
+package router
+
+import (
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"testing"
+)
+
+// TestSocketActivationDefaultName 模拟 systemd 默认的 socket activation：
+// LISTEN_FDNAMES 为 socket 单元名，监听器应按地址匹配到继承的描述符
+func TestSocketActivationDefaultName(t *testing.T) {
+	if addr := os.Getenv("SCAFFOLD_TEST_ACTIVATION_ADDR"); addr != "" {
+		// 子进程：systemd 在 exec 前设置 LISTEN_PID，这里由子进程自己补上
+		os.Setenv(envListenPID, strconv.Itoa(os.Getpid()))
+		ln, inherited, err := listen("http", "tcp", addr)
+		if err != nil {
+			t.Fatalf("listen: %v", err)
+		}
+		defer ln.Close()
+		if !inherited {
+			t.Fatal("listener not inherited")
+		}
+		if got := ln.Addr().String(); got != addr {
+			t.Fatalf("inherited addr = %s, want %s", got, addr)
+		}
+		return
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	f, err := ln.(*net.TCPListener).File()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestSocketActivationDefaultName$")
+	cmd.ExtraFiles = []*os.File{f}
+	cmd.Env = append(os.Environ(),
+		"SCAFFOLD_TEST_ACTIVATION_ADDR="+ln.Addr().String(),
+		envListenFDs+"=1",
+		envListenFDNames+"=scaffold.socket",
+	)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("child: %v\n%s", err, out)
+	}
+}
+
+func TestTakeInheritedByName(t *testing.T) {
+	inheritOnce.Do(func() {})
+
+	newLn := func() net.Listener {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { ln.Close() })
+		return ln
+	}
+	admin, other, unit := newLn(), newLn(), newLn()
+
+	tests := []struct {
+		name, listenName, addr string
+		want                   net.Listener
+	}{
+		{"by name", "admin", "127.0.0.1:1", admin},
+		{"own name never matched by address", "http", other.Addr().String(), nil},
+		{"unit name matched by address", "http", unit.Addr().String(), unit},
+		{"port only", "https", ":" + strconv.Itoa(unit.Addr().(*net.TCPAddr).Port), unit},
+		{"no match", "http", "127.0.0.1:1", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inheritMu.Lock()
+			inherited = []*inheritedListener{
+				{name: "admin", ln: admin},
+				{name: "redirect", ln: other},
+				{name: "scaffold.socket", ln: unit},
+			}
+			inheritMu.Unlock()
+
+			if got := takeInherited(tt.listenName, "tcp", tt.addr); got != tt.want {
+				t.Fatalf("takeInherited(%q, %q) = %v, want %v", tt.listenName, tt.addr, got, tt.want)
+			}
+		})
+	}
+
+	inheritMu.Lock()
+	inherited = nil
+	inheritMu.Unlock()
+}
@@ -0,0 +1,81 @@
+package router
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"scaffold/internal/config"
+	"scaffold/pkg/common/middleware"
+	"scaffold/pkg/logger"
+	"scaffold/pkg/metrics"
+)
+
+// setupAdminRoutes 注册运维接口，public 为主服务的 mux，用于输出其路由表
+func setupAdminRoutes(r *http.ServeMux, public *http.ServeMux) {
+	admin := NewRouteGroup(r, "/")
+	admin.GET("/metrics", metrics.Handler()).Summary("Prometheus 指标")
+	admin.GET("/debug/routes", RoutesHandler(public)).Summary("主服务路由列表")
+	admin.GET("/config", JSON(getConfig)).Summary("当前配置").Response(config.Config{})
+	admin.GET("/loglevel", JSON(getLogLevel)).Summary("当前日志级别").Response(LogLevel{})
+	admin.PUT("/loglevel", JSON(setLogLevel)).Summary("调整日志级别").Request(LogLevel{}).Response(LogLevel{})
+	setupDebugRoutes(admin, config.GetConfig().DebugToken)
+}
+
+// newAdminHandler 创建运维服务的路由和中间件链，不启用跨域
+func newAdminHandler(public *http.ServeMux, trusted []netip.Prefix) http.Handler {
+	r := http.NewServeMux()
+	setupAdminRoutes(r, public)
+	return defaultDeadline(
+		middleware.RequestIDMiddleware(
+			middleware.LogContext(trusted)(
+				middleware.LoggingMiddleware(
+					middleware.RecoverMiddleware(problemErrors(r))))))
+}
+
+// AdminAddr 未指定主机时只绑定本机回环地址
+func AdminAddr(addr string) string {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil || host != "" {
+		return addr
+	}
+	return net.JoinHostPort("127.0.0.1", port)
+}
+
+// getConfig 返回当前配置，敏感字段打码
+func getConfig(ctx context.Context, _ struct{}) (*config.Config, error) {
+	cfg := *config.GetConfig()
+	if cfg.DebugToken != "" {
+		cfg.DebugToken = "******"
+	}
+	if cfg.Auth.JWT.HMACSecret != "" {
+		cfg.Auth.JWT.HMACSecret = "******"
+	}
+	return &cfg, nil
+}
+
+// LogLevel 日志级别，取值 debug、info、warn、error
+type LogLevel struct {
+	Level string `json:"level"`
+}
+
+func (l LogLevel) Validate() error {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(l.Level)); err != nil {
+		return FieldErrors{{Field: "level", Message: "must be one of debug, info, warn, error"}}
+	}
+	return nil
+}
+
+func getLogLevel(ctx context.Context, _ struct{}) (LogLevel, error) {
+	return LogLevel{Level: logger.Level().String()}, nil
+}
+
+func setLogLevel(ctx context.Context, req LogLevel) (LogLevel, error) {
+	var level slog.Level
+	level.UnmarshalText([]byte(req.Level))
+	logger.SetLevel(level)
+	slog.InfoContext(ctx, "log level changed", "level", level.String())
+	return LogLevel{Level: level.String()}, nil
+}
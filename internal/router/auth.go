@@ -0,0 +1,39 @@
+package router
+
+import (
+	"fmt"
+	"scaffold/internal/config"
+	"scaffold/pkg/auth"
+	"time"
+)
+
+// newAuthenticators 根据配置创建认证器，API Key 优先于 JWT；未配置认证时返回空
+func newAuthenticators(c config.AuthConfig) ([]auth.Authenticator, error) {
+	var list []auth.Authenticator
+	if len(c.APIKeys) > 0 {
+		keys := make([]auth.APIKey, 0, len(c.APIKeys))
+		for _, k := range c.APIKeys {
+			keys = append(keys, auth.APIKey{Name: k.Name, Hash: k.Hash, Scopes: k.Scopes})
+		}
+		a, err := auth.NewAPIKeyAuthenticator(keys)
+		if err != nil {
+			return nil, fmt.Errorf("auth config invalid: %w", err)
+		}
+		list = append(list, a)
+	}
+	if c.JWT.Enabled() {
+		a, err := auth.NewJWTAuthenticator(auth.JWTOptions{
+			Issuer:     c.JWT.Issuer,
+			Audience:   c.JWT.Audience,
+			Secret:     []byte(c.JWT.HMACSecret),
+			JWKSFile:   c.JWT.JWKSFile,
+			Leeway:     time.Duration(c.JWT.Leeway) * time.Second,
+			QueryParam: c.JWT.QueryParam,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("auth config invalid: %w", err)
+		}
+		list = append(list, a)
+	}
+	return list, nil
+}
@@ -0,0 +1,22 @@
+package router
+
+import (
+	"fmt"
+	"scaffold/internal/config"
+	"scaffold/pkg/common/middleware"
+)
+
+// newCompress 根据配置创建响应压缩中间件，禁用时返回 nil
+func newCompress(c config.CompressConfig) (Middleware, error) {
+	if c.Disabled {
+		return nil, nil
+	}
+	if c.Level < 0 || c.Level > 9 {
+		return nil, fmt.Errorf("compress config invalid: level %d out of range 1-9", c.Level)
+	}
+	return middleware.Compress(middleware.CompressOptions{
+		MinSize:      c.MinSize,
+		Level:        c.Level,
+		ContentTypes: c.ContentTypes,
+	}), nil
+}
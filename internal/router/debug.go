@@ -0,0 +1,54 @@
+package router
+
+import (
+	"crypto/subtle"
+	"expvar"
+	"net/http"
+	"net/http/pprof"
+	runtimepprof "runtime/pprof"
+	"scaffold/pkg/common/problem"
+	"strings"
+)
+
+// setupDebugRoutes 在运维服务注册 pprof、trace、协程转储和 expvar，token 为空时不启用
+func setupDebugRoutes(admin *RouteGroup, token string) {
+	if token == "" {
+		return
+	}
+
+	debug := admin.Group("/debug")
+	debug.Use(requireToken(token))
+	debug.GET("/pprof/", pprof.Index).Summary("pprof 索引及命名 profile").Tags("debug")
+	debug.GET("/pprof/cmdline", pprof.Cmdline).Summary("进程命令行").Tags("debug")
+	debug.GET("/pprof/profile", pprof.Profile).Summary("CPU profile，?seconds=30").Tags("debug").Streaming()
+	debug.GET("/pprof/symbol", pprof.Symbol).Summary("符号查询").Tags("debug")
+	debug.POST("/pprof/symbol", pprof.Symbol).Summary("符号查询").Tags("debug")
+	debug.GET("/pprof/trace", pprof.Trace).Summary("runtime/trace 采集，?seconds=5").Tags("debug").Streaming()
+	debug.GET("/goroutines", dumpGoroutines).Summary("全部协程堆栈").Tags("debug").Streaming()
+	debug.GET("/vars", expvar.Handler().ServeHTTP).Summary("expvar 变量").Tags("debug")
+}
+
+// requireToken 校验 Authorization: Bearer <token>，便于浏览器访问也接受 ?token= 参数
+func requireToken(token string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok {
+				got = r.URL.Query().Get("token")
+			}
+			if subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="debug"`)
+				problem.Error(w, r, http.StatusUnauthorized, "valid debug token required")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// dumpGoroutines 以文本输出所有协程的完整堆栈
+func dumpGoroutines(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	runtimepprof.Lookup("goroutine").WriteTo(w, 2)
+}
@@ -3,20 +3,124 @@ package router
 import (
 	"net/http"
 	"path"
+	"scaffold/pkg/common/middleware"
+	"strings"
 )
 
+// Middleware 标准 http 中间件
+type Middleware func(http.Handler) http.Handler
+
+// RouteGroup 路由分组，基于 Go 1.22 ServeMux 的 "METHOD /path/{param}" 模式注册路由。
+// 同一路径注册了其他方法时，ServeMux 会自动返回 405 并设置 Allow 头。
 type RouteGroup struct {
-    prefix string
-    mux    *http.ServeMux
+	prefix      string
+	mux         *http.ServeMux
+	parent      *RouteGroup
+	middlewares []Middleware
+	cors        *middleware.CORSPolicy
 }
 
 func NewRouteGroup(mux *http.ServeMux, prefix string) *RouteGroup {
-    return &RouteGroup{
-        prefix: prefix,
-        mux:    mux,
-    }
+	return &RouteGroup{
+		prefix: prefix,
+		mux:    mux,
+	}
+}
+
+// Group 创建子分组，子分组继承父分组的前缀和中间件
+func (g *RouteGroup) Group(prefix string) *RouteGroup {
+	return &RouteGroup{
+		prefix: joinPath(g.prefix, prefix),
+		mux:    g.mux,
+		parent: g,
+	}
+}
+
+// Use 为分组添加中间件，只作用于之后在本分组及其子分组注册的路由
+func (g *RouteGroup) Use(mw ...Middleware) {
+	g.middlewares = append(g.middlewares, mw...)
+}
+
+// CORS 为分组设置跨域策略，覆盖默认策略，只作用于之后在本分组及其子分组注册的路由
+func (g *RouteGroup) CORS(policy *middleware.CORSPolicy) {
+	g.cors = policy
+}
+
+// corsPolicy 返回本分组或最近的父分组设置的跨域策略
+func (g *RouteGroup) corsPolicy() *middleware.CORSPolicy {
+	for grp := g; grp != nil; grp = grp.parent {
+		if grp.cors != nil {
+			return grp.cors
+		}
+	}
+	return nil
+}
+
+// Handle 注册路由并记录到路由表，pattern 可带方法前缀，如 "GET /users/{id}"
+func (g *RouteGroup) Handle(pattern string, handler http.HandlerFunc) *Route {
+	method, p := splitPattern(pattern)
+	return g.handle(method, p, handler)
+}
+
+// GET 注册 GET 路由（ServeMux 同时会匹配 HEAD）
+func (g *RouteGroup) GET(pattern string, handler http.HandlerFunc) *Route {
+	return g.handle(http.MethodGet, pattern, handler)
+}
+
+// POST 注册 POST 路由
+func (g *RouteGroup) POST(pattern string, handler http.HandlerFunc) *Route {
+	return g.handle(http.MethodPost, pattern, handler)
+}
+
+// PUT 注册 PUT 路由
+func (g *RouteGroup) PUT(pattern string, handler http.HandlerFunc) *Route {
+	return g.handle(http.MethodPut, pattern, handler)
+}
+
+// DELETE 注册 DELETE 路由
+func (g *RouteGroup) DELETE(pattern string, handler http.HandlerFunc) *Route {
+	return g.handle(http.MethodDelete, pattern, handler)
+}
+
+// PATCH 注册 PATCH 路由
+func (g *RouteGroup) PATCH(pattern string, handler http.HandlerFunc) *Route {
+	return g.handle(http.MethodPatch, pattern, handler)
 }
 
-func (g *RouteGroup) Handle(pattern string, handler http.HandlerFunc) {
-    g.mux.HandleFunc(path.Join(g.prefix, pattern), handler)
-}
\ No newline at end of file
+func (g *RouteGroup) handle(method, pattern string, handler http.HandlerFunc) *Route {
+	p := joinPath(g.prefix, pattern)
+	route := registryFor(g.mux).add(method, p)
+	route.reg.mu.Lock()
+	route.info.CORS = g.corsPolicy()
+	route.reg.mu.Unlock()
+	g.mux.Handle(muxPattern(method, p), routeHandler(route.reg, route.info, g.wrap(handler)))
+	return route
+}
+
+// wrap 按父分组到子分组的顺序套用中间件，先添加的中间件在最外层
+func (g *RouteGroup) wrap(h http.Handler) http.Handler {
+	for grp := g; grp != nil; grp = grp.parent {
+		for i := len(grp.middlewares) - 1; i >= 0; i-- {
+			h = grp.middlewares[i](h)
+		}
+	}
+	return h
+}
+
+// splitPattern 拆分 "METHOD /path" 形式的模式
+func splitPattern(pattern string) (method, p string) {
+	pattern = strings.TrimSpace(pattern)
+	if i := strings.IndexAny(pattern, " \t"); i >= 0 {
+		return pattern[:i], strings.TrimSpace(pattern[i+1:])
+	}
+	return "", pattern
+}
+
+// joinPath 拼接前缀与路径，保留末尾的 "/" 以支持子树匹配（如 "/static/"）
+func joinPath(prefix, p string) string {
+	full := path.Join("/", prefix, p)
+	if strings.HasSuffix(p, "/") && !strings.HasSuffix(full, "/") {
+		full += "/"
+	}
+	return full
+}
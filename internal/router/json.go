@@ -0,0 +1,128 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"scaffold/pkg/common/problem"
+	"scaffold/pkg/logger"
+)
+
+const maxBodySize = 1 << 20 // 1MB
+
+// Validator 请求类型实现该接口后，绑定完成会自动调用 Validate 校验
+type Validator interface {
+	Validate() error
+}
+
+// StatusCoder 响应类型实现该接口后，可自定义成功状态码
+type StatusCoder interface {
+	StatusCode() int
+}
+
+// JSON 将类型化处理函数适配为 http.HandlerFunc。
+// 请求体（JSON）、查询参数（query 标签）和路径参数（path 标签）绑定到 Req，
+// 返回值编码为 JSON，错误统一以 problem+json 输出。
+func JSON[Req, Resp any](fn func(context.Context, Req) (Resp, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req Req
+		if err := bind(r, &req); err != nil {
+			WriteError(w, r, err)
+			return
+		}
+
+		resp, err := fn(r.Context(), req)
+		if err != nil {
+			WriteError(w, r, err)
+			return
+		}
+
+		status := http.StatusOK
+		if sc, ok := any(resp).(StatusCoder); ok {
+			status = sc.StatusCode()
+		}
+		WriteJSON(w, status, resp)
+	}
+}
+
+// WriteJSON 输出 JSON 响应
+func WriteJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if status == http.StatusNoContent {
+		return
+	}
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		logger.WithPrefix("HTTP").Error("encode response failed", "error", err)
+	}
+}
+
+// WriteError 将错误映射为状态码并以 problem+json 输出，5xx 错误会记录日志
+func WriteError(w http.ResponseWriter, r *http.Request, err error) {
+	var pe *ParamError
+	if errors.As(err, &pe) {
+		err = problem.Wrap(http.StatusBadRequest, pe)
+	}
+
+	p := problem.From(err)
+	if p.Status >= http.StatusInternalServerError {
+		logger.FromContext(r.Context()).With("prefix", "HTTP").Error("handler failed", "error", err)
+	}
+	problem.Write(w, r, p)
+}
+
+// bind 依次绑定请求体、路径参数和查询参数，最后执行校验
+func bind(r *http.Request, dst any) error {
+	if r.Body != nil && r.Body != http.NoBody {
+		dec := json.NewDecoder(http.MaxBytesReader(nil, r.Body, maxBodySize))
+		if err := dec.Decode(dst); err != nil && !errors.Is(err, io.EOF) {
+			var mbe *http.MaxBytesError
+			if errors.As(err, &mbe) {
+				return err
+			}
+			return problem.BadRequest("invalid JSON body: " + err.Error())
+		}
+	}
+
+	if err := bindParams(r, dst); err != nil {
+		return err
+	}
+
+	if v, ok := dst.(Validator); ok {
+		if err := v.Validate(); err != nil {
+			return validationProblem(err)
+		}
+	}
+	return nil
+}
+
+// validationProblem 将 Validate 返回的错误转换为 422
+func validationProblem(err error) error {
+	var p *problem.Problem
+	if errors.As(err, &p) {
+		return err
+	}
+	var fe problem.FieldError
+	if errors.As(err, &fe) {
+		return problem.Validation(fe)
+	}
+	var fes FieldErrors
+	if errors.As(err, &fes) {
+		return problem.Validation(fes...)
+	}
+	pr := problem.Validation()
+	pr.Detail = err.Error()
+	return pr
+}
+
+// FieldErrors 多个字段错误，可作为 Validate 的返回值
+type FieldErrors []problem.FieldError
+
+func (fe FieldErrors) Error() string {
+	if len(fe) == 0 {
+		return "validation failed"
+	}
+	return fe[0].Error()
+}
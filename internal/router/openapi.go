@@ -0,0 +1,249 @@
+package router
+
+import (
+	"embed"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+)
+
+//go:embed docs.html
+var docsFS embed.FS
+
+// OpenAPIInfo 文档基本信息
+type OpenAPIInfo struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+var (
+	pathParamRe     = regexp.MustCompile(`\{([^}.$]+)(\.\.\.)?\}`)
+	componentNameRe = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+	timeType        = reflect.TypeOf(time.Time{})
+)
+
+// OpenAPI 根据 mux 上的路由表生成 OpenAPI 3.1 文档
+func OpenAPI(mux *http.ServeMux, info OpenAPIInfo) map[string]any {
+	gen := &schemaGen{components: map[string]any{}}
+	paths := map[string]map[string]any{}
+
+	for _, ri := range Routes(mux) {
+		p := strings.ReplaceAll(ri.Path, "{$}", "")
+		p = pathParamRe.ReplaceAllString(p, "{$1}")
+		if paths[p] == nil {
+			paths[p] = map[string]any{}
+		}
+
+		op := gen.operation(ri)
+		if ri.Method == "" {
+			// 未限定方法的路由以 get 描述，并通过扩展字段标注
+			op["x-any-method"] = true
+			paths[p]["get"] = op
+			continue
+		}
+		paths[p][strings.ToLower(ri.Method)] = op
+	}
+
+	doc := map[string]any{
+		"openapi": "3.1.0",
+		"info":    info,
+		"paths":   paths,
+	}
+	if len(gen.components) > 0 {
+		doc["components"] = map[string]any{"schemas": gen.components}
+	}
+	return doc
+}
+
+// OpenAPIHandler 输出 OpenAPI JSON 文档
+func OpenAPIHandler(mux *http.ServeMux, info OpenAPIInfo) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(OpenAPI(mux, info))
+	}
+}
+
+// DocsHandler 输出内嵌的接口文档页面，页面从 /openapi.json 读取文档
+func DocsHandler(w http.ResponseWriter, r *http.Request) {
+	data, _ := docsFS.ReadFile("docs.html")
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(data)
+}
+
+type schemaGen struct {
+	components map[string]any
+}
+
+func (g *schemaGen) operation(ri RouteInfo) map[string]any {
+	op := map[string]any{}
+	if ri.Summary != "" {
+		op["summary"] = ri.Summary
+	}
+	if len(ri.Tags) > 0 {
+		op["tags"] = ri.Tags
+	}
+
+	// 路径参数以路由模式为准，请求类型中 query 标签的字段作为查询参数
+	var params []map[string]any
+	for _, m := range pathParamRe.FindAllStringSubmatch(ri.Path, -1) {
+		params = append(params, map[string]any{
+			"name":     m[1],
+			"in":       "path",
+			"required": true,
+			"schema":   map[string]any{"type": "string"},
+		})
+	}
+
+	if t := deref(ri.Request); t != nil && t.Kind() == reflect.Struct {
+		hasBody := false
+		for _, f := range reflect.VisibleFields(t) {
+			if !f.IsExported() || f.Anonymous {
+				continue
+			}
+			if name := f.Tag.Get("query"); name != "" {
+				params = append(params, map[string]any{
+					"name":   name,
+					"in":     "query",
+					"schema": g.schema(f.Type),
+				})
+				continue
+			}
+			if f.Tag.Get("path") == "" && f.Tag.Get("json") != "-" {
+				hasBody = true
+			}
+		}
+		if hasBody && methodHasBody(ri.Method) {
+			op["requestBody"] = map[string]any{
+				"required": true,
+				"content": map[string]any{
+					"application/json": map[string]any{"schema": g.bodySchema(t)},
+				},
+			}
+		}
+	}
+	if len(params) > 0 {
+		op["parameters"] = params
+	}
+
+	ok := map[string]any{"description": "OK"}
+	if ri.Response != nil {
+		ok["content"] = map[string]any{
+			"application/json": map[string]any{"schema": g.schema(ri.Response)},
+		}
+	}
+	op["responses"] = map[string]any{"200": ok}
+	return op
+}
+
+// bodySchema 生成请求体结构，排除 path/query 字段
+func (g *schemaGen) bodySchema(t reflect.Type) map[string]any {
+	props := map[string]any{}
+	for _, f := range reflect.VisibleFields(t) {
+		if !f.IsExported() || f.Anonymous || f.Tag.Get("path") != "" || f.Tag.Get("query") != "" {
+			continue
+		}
+		name, _, skip := jsonName(f)
+		if skip {
+			continue
+		}
+		props[name] = g.schema(f.Type)
+	}
+	return map[string]any{"type": "object", "properties": props}
+}
+
+// schema 将 Go 类型转换为 JSON Schema，具名结构体放入 components
+func (g *schemaGen) schema(t reflect.Type) map[string]any {
+	t = deref(t)
+	if t == nil {
+		return map[string]any{}
+	}
+	if t == timeType {
+		return map[string]any{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string", "contentEncoding": "base64"}
+		}
+		return map[string]any{"type": "array", "items": g.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": g.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		name := componentNameRe.ReplaceAllString(t.String(), "_")
+		if _, ok := g.components[name]; !ok {
+			// 先占位，避免递归类型死循环
+			g.components[name] = map[string]any{}
+			g.components[name] = g.structSchema(t)
+		}
+		return map[string]any{"$ref": "#/components/schemas/" + name}
+	}
+	return map[string]any{}
+}
+
+func (g *schemaGen) structSchema(t reflect.Type) map[string]any {
+	props := map[string]any{}
+	var required []string
+	for _, f := range reflect.VisibleFields(t) {
+		if !f.IsExported() || f.Anonymous {
+			continue
+		}
+		name, omitempty, skip := jsonName(f)
+		if skip {
+			continue
+		}
+		props[name] = g.schema(f.Type)
+		if !omitempty && f.Type.Kind() != reflect.Pointer {
+			required = append(required, name)
+		}
+	}
+	s := map[string]any{"type": "object", "properties": props}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	return s
+}
+
+// jsonName 解析字段的 json 标签
+func jsonName(f reflect.StructField) (name string, omitempty, skip bool) {
+	tag := f.Tag.Get("json")
+	if tag == "-" {
+		return "", false, true
+	}
+	name, opts, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = f.Name
+	}
+	return name, strings.Contains(opts, "omitempty") || strings.Contains(opts, "omitzero"), false
+}
+
+func deref(t reflect.Type) reflect.Type {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+func methodHasBody(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, "":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+package router
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// ParamError 路径参数缺失或类型转换失败
+type ParamError struct {
+	Name  string
+	Value string
+	Err   error
+}
+
+func (e *ParamError) Error() string {
+	return fmt.Sprintf("invalid path param %q=%q: %v", e.Name, e.Value, e.Err)
+}
+
+func (e *ParamError) Unwrap() error {
+	return e.Err
+}
+
+// PathString 获取字符串路径参数，参数为空时返回错误
+func PathString(r *http.Request, name string) (string, error) {
+	v := r.PathValue(name)
+	if v == "" {
+		return "", &ParamError{Name: name, Err: errors.New("missing")}
+	}
+	return v, nil
+}
+
+// PathInt 获取 int 类型路径参数
+func PathInt(r *http.Request, name string) (int, error) {
+	v := r.PathValue(name)
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, &ParamError{Name: name, Value: v, Err: err}
+	}
+	return n, nil
+}
+
+// PathInt64 获取 int64 类型路径参数
+func PathInt64(r *http.Request, name string) (int64, error) {
+	v := r.PathValue(name)
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return 0, &ParamError{Name: name, Value: v, Err: err}
+	}
+	return n, nil
+}
+
+// PathUint64 获取 uint64 类型路径参数
+func PathUint64(r *http.Request, name string) (uint64, error) {
+	v := r.PathValue(name)
+	n, err := strconv.ParseUint(v, 10, 64)
+	if err != nil {
+		return 0, &ParamError{Name: name, Value: v, Err: err}
+	}
+	return n, nil
+}
@@ -0,0 +1,43 @@
+package router
+
+import (
+	"fmt"
+	"net/netip"
+	"scaffold/internal/config"
+	"scaffold/pkg/common/middleware"
+	"scaffold/pkg/ratelimit"
+	"time"
+)
+
+// newRateLimit 根据配置创建限流中间件，未配置时返回 nil。
+// 返回的中间件可用于多个分组，各分组共享同一组令牌桶。
+// failedAuth 按客户端 IP 限制认证失败次数，放在认证中间件之前，配额与 limit 相同。
+// trusted 为可信代理，决定客户端 IP 的来源。
+func newRateLimit(c config.RateLimitConfig, trusted []netip.Prefix) (limit, failedAuth Middleware, err error) {
+	if c.Requests <= 0 {
+		return nil, nil, nil
+	}
+	window := time.Duration(c.Window) * time.Second
+	if window <= 0 {
+		window = time.Second
+	}
+	ipKey := middleware.IPKey(trusted)
+
+	var key middleware.RateLimitKey
+	switch c.Key {
+	case "", "ip":
+		key = ipKey
+	case "identity":
+		key = middleware.IdentityKey(ipKey)
+	case "route":
+		key = middleware.KeyByRoute
+	default:
+		return nil, nil, fmt.Errorf("rate limit config invalid: unknown key %q", c.Key)
+	}
+
+	limiter := ratelimit.New(c.Requests, window, c.Burst)
+	limiter.SetMaxKeys(c.MaxKeys)
+	authLimiter := ratelimit.New(c.Requests, window, c.Burst)
+	authLimiter.SetMaxKeys(c.MaxKeys)
+	return middleware.RateLimit(limiter, key), middleware.LimitFailedAuth(authLimiter, ipKey), nil
+}
@@ -1,44 +1,235 @@
 package router
 
 import (
-	"fmt"
-	"log/slog"
 	"net/http"
-	"os"
+	"net/netip"
 	"scaffold/internal/config"
 	"scaffold/internal/index/api"
+	"scaffold/pkg/common"
 	"scaffold/pkg/common/middleware"
-
-	"time"
+	"scaffold/pkg/common/problem"
+	"scaffold/pkg/eventbus"
+	"scaffold/pkg/health"
+	"scaffold/pkg/sse"
+	"scaffold/pkg/websocket"
+	"scaffold/web"
+	"strings"
 )
 
-func setupRoutes(r *http.ServeMux) {
-	r.HandleFunc("/index", api.IndexHandler)
+// wsHub WebSocket 订阅中心，由 setupRoutes 创建，接管的连接需在服务关闭时单独断开
+var wsHub *websocket.Hub
+
+func setupRoutes(r *http.ServeMux, trusted []netip.Prefix) error {
+	authenticators, err := newAuthenticators(config.GetConfig().Auth)
+	if err != nil {
+		return err
+	}
+	limit, failedAuth, err := newRateLimit(config.GetConfig().RateLimit, trusted)
+	if err != nil {
+		return err
+	}
+
+	root := NewRouteGroup(r, "/")
+
+	// 业务接口，按配置限流
+	public := root.Group("/")
+	if limit != nil {
+		public.Use(limit)
+	}
+	public.Handle("/index", JSON(api.Index)).
+		Summary("服务状态").
+		Response(api.IndexResponse{})
+
+	// 健康检查
+	root.GET("/healthz", health.LivenessHandler).Summary("存活探针").Tags("health")
+	root.GET("/readyz", health.ReadinessHandler).Summary("就绪探针").Tags("health").Response(health.Report{})
+
+	// 需要认证的接口，未配置认证时匿名访问；限流在认证之后，以便按调用方限流，
+	// 认证失败的请求在认证之前按 IP 限流
+	secured := root.Group("/")
+	if len(authenticators) > 0 {
+		if failedAuth != nil {
+			secured.Use(failedAuth)
+		}
+		secured.Use(middleware.Authenticate(authenticators...), middleware.RequireIdentity())
+	}
+	if limit != nil {
+		secured.Use(limit)
+	}
+
+	// 事件推送。未配置认证时 /ws 匿名访问，只允许订阅，不允许发布命令
+	wsOpts := websocket.Options{}
+	if len(authenticators) > 0 {
+		wsOpts.Commands = []string{"cmd.*"}
+	}
+	wsHub = websocket.NewHub(eventbus.Default, wsOpts)
+	secured.GET("/events", sse.NewBroker(eventbus.Default, sse.Options{}).ServeHTTP).
+		Summary("事件推送（SSE），?topics=a,b 筛选主题").
+		Streaming()
+	secured.GET("/ws", wsHub.ServeHTTP).
+		Summary("WebSocket 双向消息，控制帧订阅/取消订阅主题，配置认证后可发布 cmd.* 命令").
+		Streaming()
+	health.RegisterInfo("websocket_connections", func() any { return wsHub.Count() })
+
+	// 接口文档
+	docInfo := OpenAPIInfo{
+		Title:       config.GetConfig().Service.DisplayName,
+		Version:     common.Version,
+		Description: config.GetConfig().Service.Description,
+	}
+	root.GET("/openapi.json", OpenAPIHandler(r, docInfo)).Summary("OpenAPI 文档").Tags("debug")
+	root.GET("/docs", DocsHandler).Summary("接口文档页面").Tags("debug")
+
+	// 前端单页应用
+	root.Handle("/", spaFallback(r, web.New(config.GetConfig().WebDir))).Summary("前端单页应用")
+	return nil
+}
+
+// fallbackMethods 兜底路由探测的方法，HEAD 随 GET 匹配
+var fallbackMethods = []string{
+	http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+	http.MethodPatch, http.MethodDelete, http.MethodOptions,
 }
 
-func ListenAndServe() {
-	if config.GetConfig().ListenPort == "" {
-		return
+// spaFallback 前端单页应用的兜底路由。"/" 不带方法注册，以免与不带方法的路由冲突；
+// 路径已按其他方法注册时返回 405 并设置 Allow，而不是落入前端页面
+func spaFallback(mux *http.ServeMux, next http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if allow := allowedMethods(mux, r); len(allow) > 0 {
+			w.Header().Set("Allow", strings.Join(allow, ", "))
+			problem.Error(w, r, http.StatusMethodNotAllowed, "")
+			return
+		}
+		next.ServeHTTP(w, r)
 	}
+}
+
+// allowedMethods 返回请求路径在兜底路由之外注册的方法
+func allowedMethods(mux *http.ServeMux, r *http.Request) []string {
+	var allow []string
+	for _, m := range fallbackMethods {
+		r2 := *r
+		r2.Method = m
+		if _, pattern := mux.Handler(&r2); pattern != "" && pattern != "/" {
+			allow = append(allow, m)
+		}
+	}
+	return allow
+}
+
+// problemErrors 将 ServeMux 自身生成的 404/405 响应改为 problem+json，保留 Allow 头
+func problemErrors(mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h, pattern := mux.Handler(r)
+		if pattern != "" || r.RequestURI == "*" {
+			mux.ServeHTTP(w, r)
+			return
+		}
+		// 未匹配时 mux 返回的是 404 或 405 处理器，只取其状态码和 Allow 头
+		rec := &statusRecorder{header: http.Header{}}
+		h.ServeHTTP(rec, r)
+		if allow := rec.header.Get("Allow"); allow != "" {
+			w.Header().Set("Allow", allow)
+		}
+		problem.Error(w, r, rec.status, "")
+	})
+}
 
+// statusRecorder 只记录状态码和响应头，丢弃响应体
+type statusRecorder struct {
+	header http.Header
+	status int
+}
+
+func (s *statusRecorder) Header() http.Header { return s.header }
+
+func (s *statusRecorder) Write(p []byte) (int, error) {
+	if s.status == 0 {
+		s.status = http.StatusOK
+	}
+	return len(p), nil
+}
+
+func (s *statusRecorder) WriteHeader(code int) {
+	if s.status == 0 {
+		s.status = code
+	}
+}
+
+// routePattern 返回请求在 mux 上匹配的路由模式（去掉方法部分），未匹配时返回 "unmatched"
+func routePattern(mux *http.ServeMux) func(*http.Request) string {
+	return func(r *http.Request) string {
+		_, pattern := mux.Handler(r)
+		if pattern == "" {
+			return "unmatched"
+		}
+		_, p := splitPattern(pattern)
+		return p
+	}
+}
+
+// routeCORS 返回请求匹配路由上由分组设置的跨域策略，预检请求按 Access-Control-Request-Method 匹配
+func routeCORS(mux *http.ServeMux) func(*http.Request) *middleware.CORSPolicy {
+	return func(r *http.Request) *middleware.CORSPolicy {
+		if middleware.IsPreflight(r) {
+			r2 := *r
+			r2.Method = strings.ToUpper(r.Header.Get("Access-Control-Request-Method"))
+			r = &r2
+		}
+		_, pattern := mux.Handler(r)
+		if info, ok := registryFor(mux).lookup(pattern); ok {
+			return info.CORS
+		}
+		return nil
+	}
+}
+
+// newCORSPolicy 根据配置创建默认跨域策略，未配置来源时返回 nil
+func newCORSPolicy(c config.CORSConfig) (*middleware.CORSPolicy, error) {
+	if len(c.AllowedOrigins) == 0 {
+		return nil, nil
+	}
+	return middleware.NewCORSPolicy(middleware.CORSOptions{
+		AllowedOrigins:   c.AllowedOrigins,
+		AllowedMethods:   c.AllowedMethods,
+		AllowedHeaders:   c.AllowedHeaders,
+		ExposedHeaders:   c.ExposedHeaders,
+		AllowCredentials: c.AllowCredentials,
+		MaxAge:           c.MaxAge,
+	})
+}
+
+// newHandler 创建主服务的路由和中间件链，同时返回路由 mux 供运维服务读取路由表。
+// trusted 为可信代理，日志和限流使用同一客户端 IP。
+func newHandler(trusted []netip.Prefix) (http.Handler, *http.ServeMux, error) {
 	r := http.NewServeMux()
 
 	// 设置路由
-	setupRoutes(r)
+	if err := setupRoutes(r, trusted); err != nil {
+		return nil, nil, err
+	}
 
-	// 应用中间件
-	mux := middleware.LoggingMiddleware(middleware.CorsMiddleware(r))
+	cors, err := newCORSPolicy(config.GetConfig().CORS)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	srv := &http.Server{
-		Handler: mux,
-		Addr:    config.GetConfig().ListenPort,
-		// Good practice: enforce timeouts for servers you create!
-		WriteTimeout: 3 * time.Second,
-		ReadTimeout:  3 * time.Second,
+	// 压缩在恢复中间件外层，panic 时先写出 500 响应再结束压缩流
+	var h http.Handler = middleware.RecoverMiddleware(
+		middleware.CorsMiddleware(cors, routeCORS(r))(problemErrors(r)))
+	compress, err := newCompress(config.GetConfig().Compress)
+	if err != nil {
+		return nil, nil, err
 	}
-	slog.Info(fmt.Sprintf("Server is listening on http://127.0.0.1%s", srv.Addr))
-	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-		slog.Error("Server failed", "error", err)
-		os.Exit(1)
+	if compress != nil {
+		h = compress(h)
 	}
+
+	// 应用中间件
+	return defaultDeadline(
+		middleware.RequestIDMiddleware(
+			middleware.ClientCertMiddleware(
+				middleware.LogContext(trusted)(
+					middleware.LoggingMiddleware(
+						middleware.MetricsMiddleware(routePattern(r))(h)))))), r, nil
 }
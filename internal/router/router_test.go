@@ -0,0 +1,138 @@
+package router
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"scaffold/internal/config"
+	"scaffold/pkg/common/problem"
+	"testing"
+)
+
+// TestRoutesMethodNotAllowed 路由表可以注册，方法不匹配的请求返回 405 而不是落入前端页面
+func TestRoutesMethodNotAllowed(t *testing.T) {
+	if err := config.InitConfig(); err != nil {
+		t.Fatal(err)
+	}
+	h, _, err := newHandler(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		method, path string
+		status       int
+		allow        string
+	}{
+		{http.MethodGet, "/index", http.StatusOK, ""},
+		{http.MethodPost, "/readyz", http.StatusMethodNotAllowed, "GET, HEAD"},
+		{http.MethodGet, "/some/page", http.StatusOK, ""},
+		{http.MethodHead, "/", http.StatusOK, ""},
+		{http.MethodPost, "/", http.StatusMethodNotAllowed, "GET, HEAD"},
+		{http.MethodDelete, "/some/page", http.StatusMethodNotAllowed, "GET, HEAD"},
+		{http.MethodGet, "/missing.js", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if got := rec.Header().Get("Allow"); got != tt.allow {
+				t.Fatalf("Allow = %q, want %q", got, tt.allow)
+			}
+			if tt.status >= 400 {
+				checkProblem(t, rec, tt.status)
+			}
+		})
+	}
+}
+
+// checkProblem 错误响应应为 problem+json
+func checkProblem(t *testing.T, rec *httptest.ResponseRecorder, status int) {
+	t.Helper()
+	if ct := rec.Header().Get("Content-Type"); ct != problem.ContentType {
+		t.Fatalf("Content-Type = %q, body = %q", ct, rec.Body.String())
+	}
+	var p problem.Problem
+	if err := json.Unmarshal(rec.Body.Bytes(), &p); err != nil {
+		t.Fatal(err)
+	}
+	if p.Status != status || p.RequestID == "" {
+		t.Fatalf("problem = %+v", p)
+	}
+}
+
+// TestMuxErrors ServeMux 自身的 404/405 也输出 problem+json
+func TestMuxErrors(t *testing.T) {
+	if err := config.InitConfig(); err != nil {
+		t.Fatal(err)
+	}
+	h := newAdminHandler(http.NewServeMux(), nil)
+
+	tests := []struct {
+		method, path string
+		status       int
+		allow        string
+	}{
+		{http.MethodGet, "/nope", http.StatusNotFound, ""},
+		{http.MethodPost, "/metrics", http.StatusMethodNotAllowed, "GET, HEAD"},
+		{http.MethodDelete, "/loglevel", http.StatusMethodNotAllowed, "GET, HEAD, PUT"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if got := rec.Header().Get("Allow"); got != tt.allow {
+				t.Fatalf("Allow = %q, want %q", got, tt.allow)
+			}
+			checkProblem(t, rec, tt.status)
+		})
+	}
+}
+
+func TestSPAFallback(t *testing.T) {
+	mux := http.NewServeMux()
+	root := NewRouteGroup(mux, "/")
+	ok := func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("route")) }
+	root.POST("/submit", ok)
+	root.GET("/users/{id}", ok)
+	root.DELETE("/users/{id}", ok)
+	// 不带方法的路由可以与兜底路由共存
+	root.Handle("/any", ok)
+	root.Handle("/", spaFallback(mux, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("spa"))
+	})))
+
+	tests := []struct {
+		method, path string
+		status       int
+		allow, body  string
+	}{
+		{http.MethodGet, "/submit", http.StatusMethodNotAllowed, "POST", ""},
+		{http.MethodPost, "/submit", http.StatusOK, "", "route"},
+		{http.MethodPut, "/users/1", http.StatusMethodNotAllowed, "GET, HEAD, DELETE", ""},
+		{http.MethodPatch, "/any", http.StatusOK, "", "route"},
+		{http.MethodGet, "/app/page", http.StatusOK, "", "spa"},
+		{http.MethodPost, "/app/page", http.StatusOK, "", "spa"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if got := rec.Header().Get("Allow"); got != tt.allow {
+				t.Fatalf("Allow = %q, want %q", got, tt.allow)
+			}
+			if tt.body != "" && rec.Body.String() != tt.body {
+				t.Fatalf("body = %q, want %q", rec.Body.String(), tt.body)
+			}
+		})
+	}
+}
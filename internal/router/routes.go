@@ -0,0 +1,161 @@
+package router
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"scaffold/pkg/common/middleware"
+	"sort"
+	"sync"
+	"time"
+)
+
+// RouteInfo 已注册路由的描述信息
+type RouteInfo struct {
+	Method   string       `json:"method"`
+	Path     string       `json:"path"`
+	Summary  string       `json:"summary,omitempty"`
+	Tags     []string     `json:"tags,omitempty"`
+	Request  reflect.Type `json:"-"`
+	Response reflect.Type `json:"-"`
+
+	Timeout   time.Duration `json:"-"`
+	Streaming bool          `json:"streaming,omitempty"`
+
+	CORS *middleware.CORSPolicy `json:"-"` // 分组覆盖的跨域策略，nil 时使用默认策略
+}
+
+// MarshalJSON 输出时将请求/响应类型转为类型名
+func (ri RouteInfo) MarshalJSON() ([]byte, error) {
+	type alias RouteInfo
+	out := struct {
+		alias
+		Method       string `json:"method"`
+		RequestType  string `json:"request_type,omitempty"`
+		ResponseType string `json:"response_type,omitempty"`
+		Timeout      string `json:"timeout,omitempty"`
+	}{alias: alias(ri), Method: ri.Method}
+	if ri.Timeout > 0 {
+		out.Timeout = ri.Timeout.String()
+	}
+	if out.Method == "" {
+		out.Method = "ANY"
+	}
+	if ri.Request != nil {
+		out.RequestType = ri.Request.String()
+	}
+	if ri.Response != nil {
+		out.ResponseType = ri.Response.String()
+	}
+	return json.Marshal(out)
+}
+
+// Route 注册路由后返回，用于补充文档信息
+type Route struct {
+	reg  *registry
+	info *RouteInfo
+}
+
+// Summary 设置路由摘要
+func (r *Route) Summary(summary string) *Route {
+	r.reg.mu.Lock()
+	defer r.reg.mu.Unlock()
+	r.info.Summary = summary
+	return r
+}
+
+// Tags 设置路由标签
+func (r *Route) Tags(tags ...string) *Route {
+	r.reg.mu.Lock()
+	defer r.reg.mu.Unlock()
+	r.info.Tags = append(r.info.Tags, tags...)
+	return r
+}
+
+// Request 设置请求类型，传入该类型的零值即可
+func (r *Route) Request(v any) *Route {
+	r.reg.mu.Lock()
+	defer r.reg.mu.Unlock()
+	r.info.Request = reflect.TypeOf(v)
+	return r
+}
+
+// Response 设置响应类型，传入该类型的零值即可
+func (r *Route) Response(v any) *Route {
+	r.reg.mu.Lock()
+	defer r.reg.mu.Unlock()
+	r.info.Response = reflect.TypeOf(v)
+	return r
+}
+
+// registry 记录某个 ServeMux 上注册的所有路由
+type registry struct {
+	mu        sync.RWMutex
+	routes    []*RouteInfo
+	byPattern map[string]*RouteInfo // ServeMux 模式 -> 路由
+}
+
+var registries sync.Map // *http.ServeMux -> *registry
+
+func registryFor(mux *http.ServeMux) *registry {
+	v, _ := registries.LoadOrStore(mux, &registry{})
+	return v.(*registry)
+}
+
+func (reg *registry) add(method, path string) *Route {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	info := &RouteInfo{Method: method, Path: path}
+	reg.routes = append(reg.routes, info)
+	if reg.byPattern == nil {
+		reg.byPattern = make(map[string]*RouteInfo)
+	}
+	reg.byPattern[muxPattern(method, path)] = info
+	return &Route{reg: reg, info: info}
+}
+
+// lookup 按 ServeMux 返回的模式查找路由
+func (reg *registry) lookup(pattern string) (RouteInfo, bool) {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+	info, ok := reg.byPattern[pattern]
+	if !ok {
+		return RouteInfo{}, false
+	}
+	return *info, true
+}
+
+// muxPattern 组合注册到 ServeMux 的模式
+func muxPattern(method, path string) string {
+	if method == "" {
+		return path
+	}
+	return method + " " + path
+}
+
+// Routes 返回 mux 上通过 RouteGroup 注册的路由，按路径和方法排序
+func Routes(mux *http.ServeMux) []RouteInfo {
+	reg := registryFor(mux)
+	reg.mu.RLock()
+	out := make([]RouteInfo, 0, len(reg.routes))
+	for _, ri := range reg.routes {
+		out = append(out, *ri)
+	}
+	reg.mu.RUnlock()
+
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Path != out[j].Path {
+			return out[i].Path < out[j].Path
+		}
+		return out[i].Method < out[j].Method
+	})
+	return out
+}
+
+// RoutesHandler 以 JSON 输出 mux 上的路由列表
+func RoutesHandler(mux *http.ServeMux) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(Routes(mux))
+	}
+}
@@ -0,0 +1,265 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"scaffold/internal/config"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// boundListener 已绑定的监听器及其对应的 http.Server
+type boundListener struct {
+	name string
+	url  string
+	ln   net.Listener
+	srv  *http.Server
+	tls  bool
+}
+
+func (l *boundListener) serve() error {
+	if l.tls {
+		return l.srv.ServeTLS(l.ln, "", "")
+	}
+	return l.srv.Serve(l.ln)
+}
+
+var (
+	activeMu sync.Mutex
+	active   []*boundListener
+
+	// drained 在 Shutdown 排空所有请求后关闭
+	drained     = make(chan struct{})
+	drainedOnce sync.Once
+)
+
+// ListenAndServe 按配置绑定 TCP/Unix 监听器并阻塞提供服务，直到 Shutdown 被调用
+func ListenAndServe() {
+	cfg := config.GetConfig()
+	if cfg.ListenPort == "" && cfg.UnixSocket == "" {
+		return
+	}
+
+	listeners, err := bindListeners(cfg)
+	if err != nil {
+		slog.Error("Server failed", "error", err)
+		os.Exit(1)
+	}
+	serve(listeners)
+}
+
+// bindListeners 创建主服务及各监听器，任一监听失败时关闭已打开的监听器
+func bindListeners(cfg *config.Config) (listeners []*boundListener, err error) {
+	defer func() {
+		if err != nil {
+			for _, l := range listeners {
+				l.ln.Close()
+			}
+		}
+	}()
+
+	trusted, err := parseTrustedProxies(cfg.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+	handler, publicMux, err := newHandler(trusted)
+	if err != nil {
+		return nil, err
+	}
+	srv := newServer(handler, cfg.H2C)
+	srv.RegisterOnShutdown(wsHub.Close)
+
+	tlsCfg := cfg.TLS
+	if tlsCfg.Enabled() {
+		if srv.TLSConfig, err = newTLSConfig(tlsCfg); err != nil {
+			return nil, fmt.Errorf("TLS config invalid: %w", err)
+		}
+	}
+
+	if cfg.ListenPort != "" {
+		scheme := "http"
+		if tlsCfg.Enabled() {
+			scheme = "https"
+		}
+		ln, _, err := listen(scheme, "tcp", cfg.ListenPort)
+		if err != nil {
+			return listeners, err
+		}
+		listeners = append(listeners, &boundListener{
+			name: scheme,
+			url:  fmt.Sprintf("%s://127.0.0.1%s", scheme, cfg.ListenPort),
+			ln:   ln,
+			srv:  srv,
+			tls:  tlsCfg.Enabled(),
+		})
+
+		if tlsCfg.Enabled() && tlsCfg.RedirectHTTP != "" {
+			ln, _, err := listen("redirect", "tcp", tlsCfg.RedirectHTTP)
+			if err != nil {
+				return listeners, err
+			}
+			_, httpsPort, _ := net.SplitHostPort(cfg.ListenPort)
+			listeners = append(listeners, &boundListener{
+				name: "redirect",
+				url:  "http://127.0.0.1" + tlsCfg.RedirectHTTP,
+				ln:   ln,
+				srv:  newServer(defaultDeadline(redirectHandler(httpsPort)), false),
+			})
+		}
+	}
+
+	if cfg.UnixSocket != "" {
+		ln, err := listenUnix("unix", cfg.UnixSocket, cfg.SocketMode)
+		if err != nil {
+			return listeners, err
+		}
+		listeners = append(listeners, &boundListener{
+			name: "unix",
+			url:  "unix:" + cfg.UnixSocket,
+			ln:   ln,
+			srv:  srv,
+		})
+	}
+	if cfg.AdminListen != "" {
+		addr := AdminAddr(cfg.AdminListen)
+		ln, _, err := listen("admin", "tcp", addr)
+		if err != nil {
+			return listeners, err
+		}
+		listeners = append(listeners, &boundListener{
+			name: "admin",
+			url:  "http://" + addr,
+			ln:   ln,
+			srv:  newServer(newAdminHandler(publicMux, trusted), false),
+		})
+	}
+
+	closeUnusedInherited()
+	return listeners, nil
+}
+
+// newServer 创建带超时设置的 http.Server，h2c 为 true 时允许明文 HTTP/2。
+// 写超时由 defaultDeadline 和各路由通过 ResponseController 设置，见 routeHandler。
+func newServer(handler http.Handler, h2c bool) *http.Server {
+	srv := &http.Server{
+		Handler: handler,
+		// Good practice: enforce timeouts for servers you create!
+		ReadTimeout: 3 * time.Second,
+		IdleTimeout: 60 * time.Second,
+	}
+	if h2c {
+		srv.Protocols = new(http.Protocols)
+		srv.Protocols.SetHTTP1(true)
+		srv.Protocols.SetHTTP2(true)
+		srv.Protocols.SetUnencryptedHTTP2(true)
+	}
+	return srv
+}
+
+// listenUnix 监听 Unix 域套接字，清理残留的套接字文件并设置权限；继承的套接字原样使用
+func listenUnix(name, path, mode string) (net.Listener, error) {
+	if ln := takeInherited(name, "unix", path); ln != nil {
+		return ln, nil
+	}
+
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s exists and is not a socket", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if mode != "" {
+		perm, err := strconv.ParseUint(mode, 8, 32)
+		if err != nil {
+			ln.Close()
+			return nil, fmt.Errorf("invalid unix socket mode %q: %w", mode, err)
+		}
+		if err := os.Chmod(path, os.FileMode(perm)); err != nil {
+			ln.Close()
+			return nil, err
+		}
+	}
+	return ln, nil
+}
+
+// serve 在各监听器上提供服务，阻塞直到全部退出；非正常退出时结束进程
+func serve(listeners []*boundListener) {
+	activeMu.Lock()
+	active = append(active, listeners...)
+	activeMu.Unlock()
+
+	errCh := make(chan error, len(listeners))
+	for _, l := range listeners {
+		go func() {
+			slog.Info(fmt.Sprintf("Server is listening on %s", l.url), "listener", l.name)
+			err := l.serve()
+			if err != nil && !errors.Is(err, http.ErrServerClosed) {
+				err = fmt.Errorf("%s listener: %w", l.name, err)
+			} else {
+				slog.Info("Server listener closed", "listener", l.name)
+				err = nil
+			}
+			errCh <- err
+		}()
+	}
+
+	notifyReady()
+
+	for range listeners {
+		if err := <-errCh; err != nil {
+			slog.Error("Server failed", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	// Serve 在 Shutdown 开始时立即返回，需等待进行中的请求处理完毕
+	<-drained
+}
+
+// Shutdown 优雅关闭所有服务：停止接受新连接并等待进行中的请求完成
+func Shutdown(ctx context.Context) error {
+	activeMu.Lock()
+	list := active
+	active = nil
+	activeMu.Unlock()
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	seen := map[*http.Server]bool{}
+	for _, l := range list {
+		srv := l.srv
+		if seen[srv] {
+			continue
+		}
+		seen[srv] = true
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := srv.Shutdown(ctx); err != nil {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	drainedOnce.Do(func() { close(drained) })
+	return errors.Join(errs...)
+}
@@ -0,0 +1,155 @@
+package router
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"runtime/debug"
+	"scaffold/pkg/common/middleware"
+	"scaffold/pkg/common/problem"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultWriteTimeout 未单独设置超时的路由的写超时
+	defaultWriteTimeout = 3 * time.Second
+	// timeoutGrace 超时后留给 503 响应写出的时间
+	timeoutGrace = time.Second
+)
+
+// Timeout 设置路由超时：处理超时后取消请求上下文并返回 503，写超时同步放宽
+func (r *Route) Timeout(d time.Duration) *Route {
+	r.reg.mu.Lock()
+	defer r.reg.mu.Unlock()
+	r.info.Timeout = d
+	return r
+}
+
+// Streaming 标记为流式路由（SSE、文件下载、长轮询等），不设置写超时
+func (r *Route) Streaming() *Route {
+	r.reg.mu.Lock()
+	defer r.reg.mu.Unlock()
+	r.info.Streaming = true
+	return r
+}
+
+// defaultDeadline 为所有请求设置默认写超时，覆盖 ServeMux 自身的 404/405 响应和直接注册在 mux 上的处理器，
+// routeHandler 再按路由设置延长或清除。不使用 http.Server.WriteTimeout，否则 pprof 会拒绝超过该时长的采集。
+func defaultDeadline(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.NewResponseController(w).SetWriteDeadline(time.Now().Add(defaultWriteTimeout))
+		next.ServeHTTP(w, r)
+	})
+}
+
+// routeHandler 在每次请求时按路由设置调整写超时，设置了 Timeout 的路由额外启用超时取消
+func routeHandler(reg *registry, info *RouteInfo, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reg.mu.RLock()
+		timeout, streaming := info.Timeout, info.Streaming
+		reg.mu.RUnlock()
+
+		rc := http.NewResponseController(w)
+		switch {
+		case streaming:
+			// 同时清除读超时，否则连接读超时会取消长连接请求的上下文
+			rc.SetReadDeadline(time.Time{})
+			rc.SetWriteDeadline(time.Time{})
+			h.ServeHTTP(w, r)
+		case timeout > 0:
+			rc.SetWriteDeadline(time.Now().Add(timeout + timeoutGrace))
+			serveWithTimeout(w, r, h, timeout)
+		default:
+			rc.SetWriteDeadline(time.Now().Add(defaultWriteTimeout))
+			h.ServeHTTP(w, r)
+		}
+	})
+}
+
+// serveWithTimeout 与 http.TimeoutHandler 类似：处理器输出先写入缓冲区，
+// 超时后丢弃缓冲内容并返回 JSON 格式的 503
+func serveWithTimeout(w http.ResponseWriter, r *http.Request, h http.Handler, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+	r = r.WithContext(ctx)
+
+	tw := &timeoutWriter{w: w, h: make(http.Header), req: r}
+	done := make(chan struct{})
+	panicChan := make(chan any, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				if p != http.ErrAbortHandler {
+					// 在 panic 的 goroutine 中取堆栈，调用方重新 panic 后仍能记录处理器的堆栈
+					p = &middleware.PanicError{Value: p, Stack: debug.Stack()}
+				}
+				panicChan <- p
+			}
+		}()
+		h.ServeHTTP(tw, r)
+		close(done)
+	}()
+
+	select {
+	case p := <-panicChan:
+		panic(p)
+	case <-done:
+		tw.mu.Lock()
+		defer tw.mu.Unlock()
+		dst := w.Header()
+		for k, vv := range tw.h {
+			dst[k] = vv
+		}
+		if !tw.wroteHeader {
+			tw.code = http.StatusOK
+		}
+		w.WriteHeader(tw.code)
+		w.Write(tw.buf.Bytes())
+	case <-ctx.Done():
+		tw.mu.Lock()
+		defer tw.mu.Unlock()
+		tw.timedOut = true
+		problem.Error(w, r, http.StatusServiceUnavailable, "request timed out")
+	}
+}
+
+type timeoutWriter struct {
+	w   http.ResponseWriter
+	h   http.Header
+	req *http.Request
+	buf bytes.Buffer
+
+	mu          sync.Mutex
+	timedOut    bool
+	wroteHeader bool
+	code        int
+}
+
+func (tw *timeoutWriter) Header() http.Header { return tw.h }
+
+func (tw *timeoutWriter) Write(p []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if !tw.wroteHeader {
+		tw.writeHeaderLocked(http.StatusOK)
+	}
+	return tw.buf.Write(p)
+}
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut || tw.wroteHeader {
+		return
+	}
+	tw.writeHeaderLocked(code)
+}
+
+func (tw *timeoutWriter) writeHeaderLocked(code int) {
+	tw.wroteHeader = true
+	tw.code = code
+}
@@ -0,0 +1,103 @@
+package router
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"scaffold/pkg/common/middleware"
+	"scaffold/pkg/logger"
+	"strings"
+	"testing"
+	"time"
+)
+
+func panickingHandler(w http.ResponseWriter, r *http.Request) {
+	panic("boom")
+}
+
+// TestTimeoutPanicStack 超时路由中的 panic 应记录处理器自身的堆栈
+func TestTimeoutPanicStack(t *testing.T) {
+	var buf bytes.Buffer
+	h := middleware.RecoverMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		serveWithTimeout(w, r, http.HandlerFunc(panickingHandler), time.Second)
+	}))
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r = r.WithContext(logger.NewContext(r.Context(), slog.New(slog.NewTextHandler(&buf, nil))))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, r)
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d", rec.Code)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "panic=boom") {
+		t.Fatalf("panic value not logged: %s", out)
+	}
+	if !strings.Contains(out, "router.panickingHandler") {
+		t.Fatalf("stack does not include the panicking handler: %s", out)
+	}
+}
+
+func TestTimeoutAbortHandler(t *testing.T) {
+	defer func() {
+		if p := recover(); p != http.ErrAbortHandler {
+			t.Fatalf("recovered %v, want http.ErrAbortHandler", p)
+		}
+	}()
+	serveWithTimeout(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil),
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { panic(http.ErrAbortHandler) }), time.Second)
+}
+
+// deadlineRecorder 记录通过 ResponseController 设置的写超时
+type deadlineRecorder struct {
+	*httptest.ResponseRecorder
+	deadlines []time.Time
+}
+
+func (d *deadlineRecorder) SetWriteDeadline(t time.Time) error {
+	d.deadlines = append(d.deadlines, t)
+	return nil
+}
+
+// TestDefaultWriteDeadline 未经 routeHandler 的响应（如 mux 的 404/405）也有写超时，流式路由可以清除
+func TestDefaultWriteDeadline(t *testing.T) {
+	mux := http.NewServeMux()
+	root := NewRouteGroup(mux, "/")
+	root.GET("/stream", func(w http.ResponseWriter, r *http.Request) {}).Streaming()
+	h := defaultDeadline(problemErrors(mux))
+
+	tests := []struct {
+		method, path string
+		status       int
+		last         time.Duration // 最后一次设置的写超时，0 表示清除
+	}{
+		{http.MethodGet, "/nope", http.StatusNotFound, defaultWriteTimeout},
+		{http.MethodPost, "/stream", http.StatusMethodNotAllowed, defaultWriteTimeout},
+		{http.MethodGet, "/stream", http.StatusOK, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			w := &deadlineRecorder{ResponseRecorder: httptest.NewRecorder()}
+			start := time.Now()
+			h.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d", w.Code, tt.status)
+			}
+			if len(w.deadlines) == 0 {
+				t.Fatal("no write deadline set")
+			}
+			last := w.deadlines[len(w.deadlines)-1]
+			if tt.last == 0 {
+				if !last.IsZero() {
+					t.Fatalf("deadline = %v, want cleared", last)
+				}
+				return
+			}
+			if d := last.Sub(start); d < tt.last || d > tt.last+time.Second {
+				t.Fatalf("deadline in %v, want %v", d, tt.last)
+			}
+		})
+	}
+}
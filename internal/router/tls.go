@@ -0,0 +1,43 @@
+package router
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"scaffold/internal/config"
+	"scaffold/pkg/tlsutil"
+)
+
+// newTLSConfig 根据配置创建支持证书热加载的 TLS 配置
+func newTLSConfig(c config.TLSConfig) (*tls.Config, error) {
+	reloader, err := tlsutil.NewCertReloader(c.CertFile, c.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("load tls certificate: %w", err)
+	}
+	tc, err := tlsutil.ServerConfig(reloader, c.MinVersion, c.CipherPolicy)
+	if err != nil {
+		return nil, err
+	}
+	if c.ClientCA != "" {
+		if err := tlsutil.EnableClientAuth(tc, c.ClientCA, c.ClientAuth); err != nil {
+			return nil, fmt.Errorf("load client ca: %w", err)
+		}
+	}
+	return tc, nil
+}
+
+// redirectHandler 保留 host 和路径，仅替换协议和端口
+func redirectHandler(httpsPort string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if httpsPort != "" && httpsPort != "443" {
+			host = net.JoinHostPort(host, httpsPort)
+		}
+		target := "https://" + host + r.URL.RequestURI()
+		http.Redirect(w, r, target, http.StatusMovedPermanently)
+	}
+}
@@ -0,0 +1,126 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// filer 可导出底层文件描述符的监听器
+type filer interface {
+	File() (*os.File, error)
+}
+
+// Upgrade 平滑升级：以相同参数启动新进程并传递所有监听器，等待新进程就绪后返回。
+// 返回 nil 后调用方应执行 Shutdown 排空当前进程的请求并退出；返回错误时当前进程继续服务。
+func Upgrade(ctx context.Context) error {
+	activeMu.Lock()
+	listeners := append([]*boundListener(nil), active...)
+	activeMu.Unlock()
+	if len(listeners) == 0 {
+		return errors.New("no active listeners")
+	}
+
+	files := make([]*os.File, 0, len(listeners)+1)
+	names := make([]string, 0, len(listeners))
+	defer func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}()
+	for _, l := range listeners {
+		fl, ok := l.ln.(filer)
+		if !ok {
+			return fmt.Errorf("%s listener cannot be passed to child", l.name)
+		}
+		f, err := fl.File()
+		if err != nil {
+			return fmt.Errorf("%s listener: %w", l.name, err)
+		}
+		files = append(files, f)
+		names = append(names, l.name)
+	}
+
+	// 子进程就绪后向管道写入一个字节
+	readyR, readyW, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	defer readyR.Close()
+	files = append(files, readyW)
+
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(exe, os.Args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.ExtraFiles = files
+	cmd.Env = append(cleanEnv(os.Environ()),
+		envListenFDs+"="+strconv.Itoa(len(listeners)),
+		envListenFDNames+"="+strings.Join(names, ":"),
+		envUpgradePPID+"="+strconv.Itoa(os.Getpid()),
+		envReadyFD+"="+strconv.Itoa(listenFDsStart+len(listeners)),
+	)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	// 父进程需关闭写端，子进程异常退出时读端才能返回 EOF
+	readyW.Close()
+	files = files[:len(files)-1]
+
+	slog.Info("upgrade: child started, waiting for ready", "pid", cmd.Process.Pid)
+
+	ready := make(chan error, 1)
+	go func() {
+		buf := make([]byte, 1)
+		if _, err := readyR.Read(buf); err != nil {
+			ready <- fmt.Errorf("child exited before ready: %w", err)
+			return
+		}
+		ready <- nil
+	}()
+
+	select {
+	case err := <-ready:
+		if err != nil {
+			cmd.Wait()
+			return err
+		}
+	case <-ctx.Done():
+		cmd.Process.Kill()
+		cmd.Wait()
+		return fmt.Errorf("child not ready: %w", ctx.Err())
+	}
+
+	// 套接字文件已由子进程接管，关闭时不能删除
+	for _, l := range listeners {
+		if ul, ok := l.ln.(*net.UnixListener); ok {
+			ul.SetUnlinkOnClose(false)
+		}
+	}
+	slog.Info("upgrade: child ready, draining current process", "pid", cmd.Process.Pid)
+	return nil
+}
+
+// cleanEnv 去掉继承相关的环境变量
+func cleanEnv(env []string) []string {
+	out := make([]string, 0, len(env))
+	for _, kv := range env {
+		k, _, _ := strings.Cut(kv, "=")
+		switch k {
+		case envListenPID, envListenFDs, envListenFDNames, envUpgradePPID, envReadyFD:
+			continue
+		}
+		out = append(out, kv)
+	}
+	return out
+}
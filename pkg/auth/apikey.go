@@ -0,0 +1,86 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// APIKeyHeader 携带 API Key 的请求头，也可使用 "Authorization: ApiKey <key>"
+const APIKeyHeader = "X-API-Key"
+
+const apiKeyHashPrefix = "sha256:"
+
+// APIKey 配置中的 API Key，只保存哈希
+type APIKey struct {
+	Name   string   `json:"name"`
+	Hash   string   `json:"hash"` // "sha256:<hex>"，由 HashAPIKey 生成
+	Scopes []string `json:"scopes"`
+}
+
+// GenerateAPIKey 生成随机 API Key
+func GenerateAPIKey() string {
+	return "sk_" + rand.Text()
+}
+
+// HashAPIKey 计算写入配置的 API Key 哈希。API Key 为高熵随机串，无需慢哈希。
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return apiKeyHashPrefix + hex.EncodeToString(sum[:])
+}
+
+// APIKeyAuthenticator 静态 API Key 认证
+type APIKeyAuthenticator struct {
+	keys []apiKeyEntry
+}
+
+type apiKeyEntry struct {
+	APIKey
+	sum []byte
+}
+
+// NewAPIKeyAuthenticator 校验配置中的哈希格式并创建认证器
+func NewAPIKeyAuthenticator(keys []APIKey) (*APIKeyAuthenticator, error) {
+	a := &APIKeyAuthenticator{}
+	for _, k := range keys {
+		hexSum, ok := strings.CutPrefix(k.Hash, apiKeyHashPrefix)
+		sum, err := hex.DecodeString(hexSum)
+		if !ok || err != nil || len(sum) != sha256.Size {
+			return nil, fmt.Errorf("api key %q: hash must be %s<64 hex chars>", k.Name, apiKeyHashPrefix)
+		}
+		if k.Name == "" {
+			return nil, errors.New("api key name is required")
+		}
+		a.keys = append(a.keys, apiKeyEntry{APIKey: k, sum: sum})
+	}
+	return a, nil
+}
+
+func (a *APIKeyAuthenticator) Scheme() string { return "ApiKey" }
+
+func (a *APIKeyAuthenticator) Authenticate(r *http.Request) (*Identity, error) {
+	key := r.Header.Get(APIKeyHeader)
+	if key == "" {
+		var ok bool
+		if key, ok = strings.CutPrefix(r.Header.Get("Authorization"), "ApiKey "); !ok {
+			return nil, ErrNoCredentials
+		}
+	}
+
+	sum := sha256.Sum256([]byte(strings.TrimSpace(key)))
+	for _, k := range a.keys {
+		if subtle.ConstantTimeCompare(sum[:], k.sum) == 1 {
+			return &Identity{
+				Name:   k.Name,
+				Method: MethodAPIKey,
+				Scopes: k.Scopes,
+			}, nil
+		}
+	}
+	return nil, errors.New("invalid api key")
+}
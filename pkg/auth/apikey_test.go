@@ -0,0 +1,109 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNewAPIKeyAuthenticatorHash(t *testing.T) {
+	valid := HashAPIKey("sk_test")
+	tests := []struct {
+		name string
+		key  APIKey
+		ok   bool
+	}{
+		{"valid", APIKey{Name: "ci", Hash: valid}, true},
+		{"uppercase hex", APIKey{Name: "ci", Hash: "sha256:" + strings.ToUpper(strings.TrimPrefix(valid, "sha256:"))}, true},
+		{"missing prefix", APIKey{Name: "ci", Hash: strings.TrimPrefix(valid, "sha256:")}, false},
+		{"other algorithm", APIKey{Name: "ci", Hash: "md5:" + strings.TrimPrefix(valid, "sha256:")}, false},
+		{"short", APIKey{Name: "ci", Hash: valid[:len(valid)-2]}, false},
+		{"long", APIKey{Name: "ci", Hash: valid + "00"}, false},
+		{"not hex", APIKey{Name: "ci", Hash: valid[:len(valid)-1] + "z"}, false},
+		{"empty hash", APIKey{Name: "ci"}, false},
+		{"plaintext key", APIKey{Name: "ci", Hash: "sk_test"}, false},
+		{"empty name", APIKey{Hash: valid}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewAPIKeyAuthenticator([]APIKey{tt.key})
+			if (err == nil) != tt.ok {
+				t.Fatalf("err = %v, want ok = %v", err, tt.ok)
+			}
+		})
+	}
+}
+
+func TestAPIKeyAuthenticate(t *testing.T) {
+	key := GenerateAPIKey()
+	other := GenerateAPIKey()
+	a, err := NewAPIKeyAuthenticator([]APIKey{
+		{Name: "ci", Hash: HashAPIKey(key), Scopes: []string{"deploy"}},
+		{Name: "ops", Hash: HashAPIKey(other)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    string
+		err     error
+	}{
+		{name: "header", headers: map[string]string{APIKeyHeader: key}, want: "ci"},
+		{name: "authorization scheme", headers: map[string]string{"Authorization": "ApiKey " + other}, want: "ops"},
+		{name: "surrounding spaces", headers: map[string]string{APIKeyHeader: " " + key + " "}, want: "ci"},
+		{name: "header takes precedence", headers: map[string]string{APIKeyHeader: key, "Authorization": "ApiKey " + other}, want: "ci"},
+		{name: "no credentials", err: ErrNoCredentials},
+		{name: "bearer is not an api key", headers: map[string]string{"Authorization": "Bearer " + key}, err: ErrNoCredentials},
+		{name: "unknown key", headers: map[string]string{APIKeyHeader: "sk_unknown"}},
+		{name: "hash instead of key", headers: map[string]string{APIKeyHeader: HashAPIKey(key)}},
+		{name: "key prefix", headers: map[string]string{APIKeyHeader: key[:len(key)-1]}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+			id, err := a.Authenticate(r)
+			if tt.want != "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if id.Name != tt.want || id.Method != MethodAPIKey {
+					t.Fatalf("identity = %+v", id)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("accepted as %+v", id)
+			}
+			if tt.err != nil && !errors.Is(err, tt.err) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			if tt.err == nil && errors.Is(err, ErrNoCredentials) {
+				t.Fatal("invalid key reported as missing credentials")
+			}
+		})
+	}
+
+	id, _ := a.Authenticate(func() *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set(APIKeyHeader, key)
+		return r
+	}())
+	if !id.HasScope("deploy") || id.HasScope("admin") {
+		t.Fatalf("scopes = %v", id.Scopes)
+	}
+}
+
+func TestGenerateAPIKey(t *testing.T) {
+	a, b := GenerateAPIKey(), GenerateAPIKey()
+	if a == b || !strings.HasPrefix(a, "sk_") || len(a) < 20 {
+		t.Fatalf("generated keys %q, %q", a, b)
+	}
+}
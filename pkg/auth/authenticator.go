@@ -0,0 +1,17 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+)
+
+// ErrNoCredentials 请求未携带该认证方式的凭据，由下一个认证器继续尝试
+var ErrNoCredentials = errors.New("auth: no credentials")
+
+// Authenticator 从请求中识别调用方。请求未携带对应凭据时返回 ErrNoCredentials，
+// 凭据无效时返回其他错误。
+type Authenticator interface {
+	Authenticate(r *http.Request) (*Identity, error)
+	// Scheme 用于 WWW-Authenticate 响应头，如 "Bearer"
+	Scheme() string
+}
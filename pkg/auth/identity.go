@@ -0,0 +1,67 @@
+// Package auth 定义已认证调用方的身份及其在请求上下文中的传递
+package auth
+
+import (
+	"context"
+	"crypto/x509"
+	"slices"
+)
+
+// 认证方式
+const (
+	MethodMTLS   = "mtls"
+	MethodAPIKey = "api_key"
+	MethodJWT    = "jwt"
+)
+
+// Identity 已认证的调用方
+type Identity struct {
+	Name   string         // 主体名，客户端证书为 Subject CN，API Key 为配置的名称，JWT 为 sub
+	Method string         // 认证方式
+	SANs   []string       // 客户端证书的 DNS、URI、Email 和 IP
+	Scopes []string       // 授权范围，API Key 来自配置，JWT 来自 scope/scp 声明
+	Claims map[string]any // JWT 的全部声明
+}
+
+// Matches 主体名或任一 SAN 与 name 相同
+func (id *Identity) Matches(name string) bool {
+	return id.Name == name || slices.Contains(id.SANs, name)
+}
+
+// HasScope 是否拥有指定授权范围
+func (id *Identity) HasScope(scope string) bool {
+	return slices.Contains(id.Scopes, scope)
+}
+
+type identityKey struct{}
+
+// WithIdentity 将身份写入上下文
+func WithIdentity(ctx context.Context, id *Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, id)
+}
+
+// FromContext 从上下文读取身份
+func FromContext(ctx context.Context) (*Identity, bool) {
+	id, ok := ctx.Value(identityKey{}).(*Identity)
+	return id, ok && id != nil
+}
+
+// FromCertificate 将客户端证书转换为身份
+func FromCertificate(cert *x509.Certificate) *Identity {
+	id := &Identity{
+		Name:   cert.Subject.CommonName,
+		Method: MethodMTLS,
+	}
+	id.SANs = append(id.SANs, cert.DNSNames...)
+	id.SANs = append(id.SANs, cert.EmailAddresses...)
+	for _, u := range cert.URIs {
+		id.SANs = append(id.SANs, u.String())
+	}
+	for _, ip := range cert.IPAddresses {
+		id.SANs = append(id.SANs, ip.String())
+	}
+	if id.Name == "" && len(id.SANs) > 0 {
+		id.Name = id.SANs[0]
+	}
+	return id
+}
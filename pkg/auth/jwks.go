@@ -0,0 +1,158 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"os"
+	"sync"
+	"time"
+)
+
+// jwk JSON Web Key，支持 RSA、OKP(Ed25519) 和 oct(HMAC)
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	K   string `json:"k"`
+}
+
+// verifyKey 解析后的验签密钥
+type verifyKey struct {
+	kid string
+	alg string // 该密钥适用的算法：HS256、RS256 或 EdDSA
+	key any    // []byte、*rsa.PublicKey 或 ed25519.PublicKey
+}
+
+// parseJWKS 解析 JWKS 文档，忽略非签名用途和不支持的密钥
+func parseJWKS(data []byte) ([]verifyKey, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, err
+	}
+
+	var keys []verifyKey
+	for i, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		vk, err := k.verifyKey()
+		if err != nil {
+			return nil, fmt.Errorf("key %d (kid %q): %w", i, k.Kid, err)
+		}
+		if vk.alg == "" {
+			continue
+		}
+		keys = append(keys, vk)
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no usable signing keys")
+	}
+	return keys, nil
+}
+
+func (k jwk) verifyKey() (verifyKey, error) {
+	vk := verifyKey{kid: k.Kid}
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return vk, fmt.Errorf("invalid n: %w", err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil || len(e) == 0 || len(e) > 4 {
+			return vk, errors.New("invalid e")
+		}
+		pub := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		if pub.N.BitLen() < 2048 {
+			return vk, errors.New("rsa key shorter than 2048 bits")
+		}
+		vk.alg, vk.key = "RS256", pub
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return vk, nil
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return vk, errors.New("invalid ed25519 key")
+		}
+		vk.alg, vk.key = "EdDSA", ed25519.PublicKey(x)
+	case "oct":
+		secret, err := base64.RawURLEncoding.DecodeString(k.K)
+		if err != nil || len(secret) < 32 {
+			return vk, errors.New("hmac key must be at least 32 bytes")
+		}
+		vk.alg, vk.key = "HS256", secret
+	default:
+		return vk, nil
+	}
+	if k.Alg != "" && k.Alg != vk.alg {
+		// 声明了其他算法（如 RS512）的密钥不参与验签
+		vk.alg = ""
+	}
+	return vk, nil
+}
+
+// jwksFile 从文件加载 JWKS，文件修改后自动重新加载，便于轮换密钥
+type jwksFile struct {
+	path string
+
+	mu      sync.Mutex
+	keys    []verifyKey
+	modTime time.Time
+	checked time.Time
+}
+
+const jwksCheckInterval = 10 * time.Second
+
+func loadJWKSFile(path string) (*jwksFile, error) {
+	f := &jwksFile{path: path}
+	if err := f.reload(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *jwksFile) reload() error {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return err
+	}
+	keys, err := parseJWKS(data)
+	if err != nil {
+		return fmt.Errorf("jwks %s: %w", f.path, err)
+	}
+	f.keys, f.modTime = keys, info.ModTime()
+	return nil
+}
+
+// get 返回当前密钥，距上次检查超过间隔且文件有变化时重新加载，加载失败则沿用旧密钥
+func (f *jwksFile) get() []verifyKey {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if time.Since(f.checked) >= jwksCheckInterval {
+		f.checked = time.Now()
+		if info, err := os.Stat(f.path); err == nil && !info.ModTime().Equal(f.modTime) {
+			if err := f.reload(); err != nil {
+				slog.Warn("reload jwks failed, keep the old keys", "file", f.path, "error", err)
+			}
+		}
+	}
+	return f.keys
+}
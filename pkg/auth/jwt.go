@@ -0,0 +1,221 @@
+package auth
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// JWTOptions JWT 认证选项
+type JWTOptions struct {
+	Issuer   string   // 非空时 iss 必须相同
+	Audience []string // 非空时 aud 必须包含其中之一
+	Secret   []byte   // HS256 密钥，至少 32 字节
+	JWKSFile string   // 包含 RSA、Ed25519 或 oct 密钥的 JWKS 文件
+	// Leeway 校验 exp、nbf 时允许的时钟偏差，默认 1 分钟
+	Leeway time.Duration
+	// QueryParam 非空时也从该查询参数读取令牌，用于无法设置请求头的 WebSocket、EventSource
+	QueryParam string
+}
+
+// JWTAuthenticator 校验 HS256、RS256、EdDSA 签名的 Bearer 令牌
+type JWTAuthenticator struct {
+	opts   JWTOptions
+	static []verifyKey
+	jwks   *jwksFile
+}
+
+// NewJWTAuthenticator 创建 JWT 认证器，Secret 和 JWKSFile 至少配置一个
+func NewJWTAuthenticator(opts JWTOptions) (*JWTAuthenticator, error) {
+	a := &JWTAuthenticator{opts: opts}
+	if a.opts.Leeway <= 0 {
+		a.opts.Leeway = time.Minute
+	}
+	if len(opts.Secret) > 0 {
+		if len(opts.Secret) < 32 {
+			return nil, errors.New("jwt: hmac secret must be at least 32 bytes")
+		}
+		a.static = append(a.static, verifyKey{alg: "HS256", key: opts.Secret})
+	}
+	if opts.JWKSFile != "" {
+		f, err := loadJWKSFile(opts.JWKSFile)
+		if err != nil {
+			return nil, fmt.Errorf("jwt: %w", err)
+		}
+		a.jwks = f
+	}
+	if len(a.static) == 0 && a.jwks == nil {
+		return nil, errors.New("jwt: secret or jwks file is required")
+	}
+	return a, nil
+}
+
+func (a *JWTAuthenticator) Scheme() string { return "Bearer" }
+
+func (a *JWTAuthenticator) Authenticate(r *http.Request) (*Identity, error) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok && a.opts.QueryParam != "" {
+		token = r.URL.Query().Get(a.opts.QueryParam)
+	}
+	if token = strings.TrimSpace(token); token == "" {
+		return nil, ErrNoCredentials
+	}
+
+	claims, err := a.verify(token)
+	if err != nil {
+		return nil, err
+	}
+
+	id := &Identity{Method: MethodJWT, Claims: claims}
+	id.Name, _ = claims["sub"].(string)
+	if id.Name == "" {
+		// 客户端凭据模式的令牌可能没有 sub
+		id.Name, _ = claims["client_id"].(string)
+	}
+	if s, ok := claims["scope"].(string); ok {
+		id.Scopes = strings.Fields(s)
+	}
+	if scp, ok := claims["scp"].([]any); ok {
+		for _, v := range scp {
+			if s, ok := v.(string); ok {
+				id.Scopes = append(id.Scopes, s)
+			}
+		}
+	}
+	return id, nil
+}
+
+// verify 校验签名和标准声明，返回全部声明
+func (a *JWTAuthenticator) verify(token string) (map[string]any, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed token")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("invalid token header: %w", err)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errors.New("invalid token signature encoding")
+	}
+	if !a.verifySignature(header.Alg, header.Kid, parts[0]+"."+parts[1], sig) {
+		return nil, errors.New("invalid token signature")
+	}
+
+	var claims map[string]any
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("invalid token claims: %w", err)
+	}
+	if err := a.validateClaims(claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// verifySignature 只使用与 alg 匹配的密钥验签，防止算法混淆；kid 非空时只使用对应密钥
+func (a *JWTAuthenticator) verifySignature(alg, kid, input string, sig []byte) bool {
+	keys := a.static
+	if a.jwks != nil {
+		keys = append(slices.Clip(keys), a.jwks.get()...)
+	}
+
+	for _, k := range keys {
+		if k.alg != alg || (kid != "" && k.kid != "" && k.kid != kid) {
+			continue
+		}
+		switch key := k.key.(type) {
+		case []byte:
+			mac := hmac.New(sha256.New, key)
+			mac.Write([]byte(input))
+			if hmac.Equal(mac.Sum(nil), sig) {
+				return true
+			}
+		case *rsa.PublicKey:
+			sum := sha256.Sum256([]byte(input))
+			if rsa.VerifyPKCS1v15(key, crypto.SHA256, sum[:], sig) == nil {
+				return true
+			}
+		case ed25519.PublicKey:
+			if ed25519.Verify(key, []byte(input), sig) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (a *JWTAuthenticator) validateClaims(claims map[string]any) error {
+	now := time.Now()
+
+	exp, ok := numericDate(claims["exp"])
+	if !ok {
+		return errors.New("token has no exp claim")
+	}
+	if now.After(exp.Add(a.opts.Leeway)) {
+		return errors.New("token expired")
+	}
+	if nbf, ok := numericDate(claims["nbf"]); ok && now.Add(a.opts.Leeway).Before(nbf) {
+		return errors.New("token not valid yet")
+	}
+	if a.opts.Issuer != "" && claims["iss"] != a.opts.Issuer {
+		return errors.New("token issuer not accepted")
+	}
+	if len(a.opts.Audience) > 0 && !audienceMatches(claims["aud"], a.opts.Audience) {
+		return errors.New("token audience not accepted")
+	}
+	return nil
+}
+
+// audienceMatches aud 可以是字符串或字符串数组
+func audienceMatches(aud any, accepted []string) bool {
+	switch v := aud.(type) {
+	case string:
+		return slices.Contains(accepted, v)
+	case []any:
+		for _, a := range v {
+			if s, ok := a.(string); ok && slices.Contains(accepted, s) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func numericDate(v any) (time.Time, bool) {
+	n, ok := v.(json.Number)
+	if !ok {
+		return time.Time{}, false
+	}
+	f, err := n.Float64()
+	if err != nil {
+		return time.Time{}, false
+	}
+	sec := int64(f)
+	return time.Unix(sec, int64((f-float64(sec))*1e9)), true
+}
+
+func decodeSegment(seg string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
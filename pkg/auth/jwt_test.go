@@ -0,0 +1,323 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	testSecret = []byte("0123456789abcdef0123456789abcdef")
+	testRSA    = mustRSAKey(2048)
+	otherRSA   = mustRSAKey(2048)
+	testEdPub  ed25519.PublicKey
+	testEdKey  ed25519.PrivateKey
+)
+
+func init() {
+	var err error
+	testEdPub, testEdKey, err = ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func mustRSAKey(bits int) *rsa.PrivateKey {
+	k, err := rsa.GenerateKey(rand.Reader, bits)
+	if err != nil {
+		panic(err)
+	}
+	return k
+}
+
+func b64(data []byte) string { return base64.RawURLEncoding.EncodeToString(data) }
+
+func b64JSON(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return b64(data)
+}
+
+// signToken 按 header 中的 alg 签名，key 为 []byte、*rsa.PrivateKey 或 ed25519.PrivateKey，alg 为 none 时不签名
+func signToken(header, claims map[string]any, key any) string {
+	input := b64JSON(header) + "." + b64JSON(claims)
+	var sig []byte
+	switch k := key.(type) {
+	case []byte:
+		mac := hmac.New(sha256.New, k)
+		mac.Write([]byte(input))
+		sig = mac.Sum(nil)
+	case *rsa.PrivateKey:
+		sum := sha256.Sum256([]byte(input))
+		var err error
+		if sig, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, sum[:]); err != nil {
+			panic(err)
+		}
+	case ed25519.PrivateKey:
+		sig = ed25519.Sign(k, []byte(input))
+	}
+	return input + "." + b64(sig)
+}
+
+func writeJWKS(t *testing.T, keys ...map[string]any) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	data, _ := json.Marshal(map[string]any{"keys": keys})
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func rsaJWK(kid string, pub *rsa.PublicKey) map[string]any {
+	return map[string]any{
+		"kty": "RSA", "kid": kid, "use": "sig",
+		"n": b64(pub.N.Bytes()),
+		"e": b64(big.NewInt(int64(pub.E)).Bytes()),
+	}
+}
+
+func TestJWTAuthenticate(t *testing.T) {
+	jwks := writeJWKS(t,
+		rsaJWK("rsa-1", &testRSA.PublicKey),
+		map[string]any{"kty": "OKP", "crv": "Ed25519", "kid": "ed-1", "x": b64(testEdPub)},
+	)
+	a, err := NewJWTAuthenticator(JWTOptions{
+		Issuer:     "https://issuer.example.com",
+		Audience:   []string{"scaffold", "other"},
+		Secret:     testSecret,
+		JWKSFile:   jwks,
+		Leeway:     time.Minute,
+		QueryParam: "access_token",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now().Unix()
+	claims := func(overrides map[string]any) map[string]any {
+		c := map[string]any{
+			"sub": "alice",
+			"iss": "https://issuer.example.com",
+			"aud": "scaffold",
+			"exp": now + 300,
+		}
+		for k, v := range overrides {
+			if v == nil {
+				delete(c, k)
+			} else {
+				c[k] = v
+			}
+		}
+		return c
+	}
+	hs := map[string]any{"alg": "HS256", "typ": "JWT"}
+	rs := map[string]any{"alg": "RS256", "kid": "rsa-1"}
+	ed := map[string]any{"alg": "EdDSA", "kid": "ed-1"}
+
+	// 用 RSA 公钥作为 HMAC 密钥签名的 HS256 令牌（算法混淆攻击）
+	pubDER, _ := x509.MarshalPKIXPublicKey(&testRSA.PublicKey)
+
+	tests := []struct {
+		name  string
+		token string
+		ok    bool
+		check func(*testing.T, *Identity)
+	}{
+		{name: "hs256", token: signToken(hs, claims(nil), testSecret), ok: true},
+		{name: "rs256", token: signToken(rs, claims(nil), testRSA), ok: true},
+		{name: "rs256 without kid", token: signToken(map[string]any{"alg": "RS256"}, claims(nil), testRSA), ok: true},
+		{name: "eddsa", token: signToken(ed, claims(nil), testEdKey), ok: true},
+
+		{name: "alg none", token: signToken(map[string]any{"alg": "none"}, claims(nil), nil)},
+		{name: "alg none uppercase", token: signToken(map[string]any{"alg": "NONE"}, claims(nil), nil)},
+		{name: "alg missing", token: signToken(map[string]any{}, claims(nil), testSecret)},
+		{name: "hs256 signed with rsa public key der", token: signToken(hs, claims(nil), pubDER)},
+		{name: "hs256 signed with rsa modulus", token: signToken(hs, claims(nil), testRSA.PublicKey.N.Bytes())},
+		{name: "hs256 with rsa kid", token: signToken(map[string]any{"alg": "HS256", "kid": "rsa-1"}, claims(nil), pubDER)},
+		{name: "rs256 wrong key", token: signToken(rs, claims(nil), otherRSA)},
+		{name: "rs256 header on eddsa signature", token: signToken(map[string]any{"alg": "RS256", "kid": "ed-1"}, claims(nil), testEdKey)},
+		{name: "eddsa header with rsa kid", token: signToken(map[string]any{"alg": "EdDSA", "kid": "rsa-1"}, claims(nil), testEdKey)},
+		{name: "unknown kid", token: signToken(map[string]any{"alg": "RS256", "kid": "nope"}, claims(nil), testRSA)},
+		{name: "unsupported alg", token: signToken(map[string]any{"alg": "RS512"}, claims(nil), testRSA)},
+		{name: "wrong hmac secret", token: signToken(hs, claims(nil), []byte("another-secret-another-secret-xx"))},
+		{name: "tampered claims", token: func() string {
+			tok := signToken(hs, claims(nil), testSecret)
+			parts := strings.Split(tok, ".")
+			return parts[0] + "." + b64JSON(claims(map[string]any{"sub": "admin"})) + "." + parts[2]
+		}()},
+
+		{name: "exp missing", token: signToken(hs, claims(map[string]any{"exp": nil}), testSecret)},
+		{name: "exp string", token: signToken(hs, claims(map[string]any{"exp": "9999999999"}), testSecret)},
+		{name: "expired", token: signToken(hs, claims(map[string]any{"exp": now - 120}), testSecret)},
+		{name: "expired within leeway", token: signToken(hs, claims(map[string]any{"exp": now - 30}), testSecret), ok: true},
+		{name: "nbf future", token: signToken(hs, claims(map[string]any{"nbf": now + 120}), testSecret)},
+		{name: "nbf within leeway", token: signToken(hs, claims(map[string]any{"nbf": now + 30}), testSecret), ok: true},
+		{name: "nbf past", token: signToken(hs, claims(map[string]any{"nbf": now - 30}), testSecret), ok: true},
+
+		{name: "iss mismatch", token: signToken(hs, claims(map[string]any{"iss": "https://evil.example.com"}), testSecret)},
+		{name: "iss missing", token: signToken(hs, claims(map[string]any{"iss": nil}), testSecret)},
+		{name: "aud array", token: signToken(hs, claims(map[string]any{"aud": []string{"x", "other"}}), testSecret), ok: true},
+		{name: "aud mismatch", token: signToken(hs, claims(map[string]any{"aud": "evil"}), testSecret)},
+		{name: "aud array mismatch", token: signToken(hs, claims(map[string]any{"aud": []string{"x", "y"}}), testSecret)},
+		{name: "aud missing", token: signToken(hs, claims(map[string]any{"aud": nil}), testSecret)},
+
+		{name: "malformed segments", token: "a.b"},
+		{name: "malformed header", token: "!!!." + b64JSON(claims(nil)) + ".sig"},
+		{name: "malformed signature", token: signToken(hs, claims(nil), testSecret) + "!"},
+
+		{name: "client_id and scope", token: signToken(hs, claims(map[string]any{"sub": nil, "client_id": "svc", "scope": "read write"}), testSecret), ok: true,
+			check: func(t *testing.T, id *Identity) {
+				if id.Name != "svc" || id.Method != MethodJWT || !slices.Equal(id.Scopes, []string{"read", "write"}) {
+					t.Fatalf("identity = %+v", id)
+				}
+			}},
+		{name: "scp array", token: signToken(hs, claims(map[string]any{"scp": []string{"a", "b"}}), testSecret), ok: true,
+			check: func(t *testing.T, id *Identity) {
+				if id.Name != "alice" || !id.HasScope("b") {
+					t.Fatalf("identity = %+v", id)
+				}
+			}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set("Authorization", "Bearer "+tt.token)
+			id, err := a.Authenticate(r)
+			if tt.ok {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if tt.check != nil {
+					tt.check(t, id)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("token accepted: %+v", id)
+			}
+			if errors.Is(err, ErrNoCredentials) {
+				t.Fatalf("invalid token reported as missing credentials")
+			}
+		})
+	}
+}
+
+func TestJWTCredentialSources(t *testing.T) {
+	a, err := NewJWTAuthenticator(JWTOptions{Secret: testSecret, QueryParam: "access_token"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	token := signToken(map[string]any{"alg": "HS256"}, map[string]any{"sub": "alice", "exp": time.Now().Unix() + 60}, testSecret)
+
+	tests := []struct {
+		name   string
+		header string
+		query  string
+		err    error
+		ok     bool
+	}{
+		{name: "bearer header", header: "Bearer " + token, ok: true},
+		{name: "query param", query: "?access_token=" + token, ok: true},
+		{name: "no credentials", err: ErrNoCredentials},
+		{name: "other scheme", header: "Basic dXNlcjpwYXNz", err: ErrNoCredentials},
+		{name: "empty bearer", header: "Bearer  ", err: ErrNoCredentials},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/"+tt.query, nil)
+			if tt.header != "" {
+				r.Header.Set("Authorization", tt.header)
+			}
+			_, err := a.Authenticate(r)
+			if tt.ok && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.err != nil && !errors.Is(err, tt.err) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestNewJWTAuthenticatorInvalid(t *testing.T) {
+	small := mustRSAKey(1024)
+	tests := []struct {
+		name string
+		opts func(t *testing.T) JWTOptions
+	}{
+		{"no keys", func(t *testing.T) JWTOptions { return JWTOptions{} }},
+		{"short secret", func(t *testing.T) JWTOptions { return JWTOptions{Secret: []byte("short")} }},
+		{"missing jwks file", func(t *testing.T) JWTOptions {
+			return JWTOptions{JWKSFile: filepath.Join(t.TempDir(), "missing.json")}
+		}},
+		{"rsa key too small", func(t *testing.T) JWTOptions {
+			return JWTOptions{JWKSFile: writeJWKS(t, rsaJWK("small", &small.PublicKey))}
+		}},
+		{"short oct key", func(t *testing.T) JWTOptions {
+			return JWTOptions{JWKSFile: writeJWKS(t, map[string]any{"kty": "oct", "k": b64([]byte("short"))})}
+		}},
+		{"only unusable keys", func(t *testing.T) JWTOptions {
+			k := rsaJWK("enc", &testRSA.PublicKey)
+			k["use"] = "enc"
+			return JWTOptions{JWKSFile: writeJWKS(t, k)}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewJWTAuthenticator(tt.opts(t)); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
+
+// TestJWKSAlgPinning JWKS 中声明了其他算法的密钥不能用于验签
+func TestJWKSAlgPinning(t *testing.T) {
+	pinned := rsaJWK("pinned", &testRSA.PublicKey)
+	pinned["alg"] = "RS512"
+	oct := map[string]any{"kty": "oct", "kid": "oct", "k": b64(testSecret)}
+	a, err := NewJWTAuthenticator(JWTOptions{JWKSFile: writeJWKS(t, pinned, oct)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims := map[string]any{"sub": "alice", "exp": time.Now().Unix() + 60}
+
+	tests := []struct {
+		name  string
+		token string
+		ok    bool
+	}{
+		{"rs256 with rs512 pinned key", signToken(map[string]any{"alg": "RS256", "kid": "pinned"}, claims, testRSA), false},
+		{"oct key hs256", signToken(map[string]any{"alg": "HS256", "kid": "oct"}, claims, testSecret), true},
+		{"oct key with rsa kid", signToken(map[string]any{"alg": "HS256", "kid": "pinned"}, claims, testSecret), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set("Authorization", "Bearer "+tt.token)
+			_, err := a.Authenticate(r)
+			if (err == nil) != tt.ok {
+				t.Fatalf("err = %v, want ok = %v", err, tt.ok)
+			}
+		})
+	}
+}
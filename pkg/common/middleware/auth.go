@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"scaffold/pkg/auth"
+	"scaffold/pkg/common/problem"
+	"scaffold/pkg/logger"
+	"strings"
+)
+
+// Authenticate 依次使用认证器识别调用方，成功后将身份写入请求上下文。
+// 未携带任何凭据的请求按匿名放行，需要登录的路由再配合 RequireIdentity；凭据无效时返回 401。
+func Authenticate(authenticators ...auth.Authenticator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for _, a := range authenticators {
+				id, err := a.Authenticate(r)
+				if errors.Is(err, auth.ErrNoCredentials) {
+					continue
+				}
+				if err != nil {
+					w.Header().Set("WWW-Authenticate", a.Scheme()+` error="invalid_token"`)
+					problem.Write(w, r, problem.Unauthorized(err.Error()))
+					return
+				}
+				ctx := auth.WithIdentity(r.Context(), id)
+				ctx = logger.AppendContext(ctx, "user", id.Name)
+				next.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+
+			// 未携带凭据时提示可用的认证方式，供 RequireIdentity 的 401 响应使用
+			schemes := make([]string, 0, len(authenticators))
+			for _, a := range authenticators {
+				schemes = append(schemes, a.Scheme())
+			}
+			if _, ok := auth.FromContext(r.Context()); !ok && len(schemes) > 0 {
+				w.Header().Set("WWW-Authenticate", strings.Join(schemes, ", "))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireScope 要求已认证且拥有全部授权范围，未认证返回 401，范围不足返回 403
+func RequireScope(scopes ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id, ok := auth.FromContext(r.Context())
+			if !ok {
+				problem.Error(w, r, http.StatusUnauthorized, "authentication required")
+				return
+			}
+			for _, s := range scopes {
+				if !id.HasScope(s) {
+					problem.Error(w, r, http.StatusForbidden, "missing scope: "+s)
+					return
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
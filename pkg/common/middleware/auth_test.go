@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"scaffold/pkg/auth"
+	"testing"
+)
+
+func TestAuthenticateAndRequireScope(t *testing.T) {
+	admin, reader := auth.GenerateAPIKey(), auth.GenerateAPIKey()
+	keys, err := auth.NewAPIKeyAuthenticator([]auth.APIKey{
+		{Name: "admin", Hash: auth.HashAPIKey(admin), Scopes: []string{"read", "write"}},
+		{Name: "reader", Hash: auth.HashAPIKey(reader), Scopes: []string{"read"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, _ := auth.FromContext(r.Context())
+		if id != nil {
+			w.Header().Set("X-User", id.Name)
+		}
+	})
+
+	tests := []struct {
+		name     string
+		handler  http.Handler
+		key      string
+		status   int
+		user     string
+		wwwAuthn string
+	}{
+		{"anonymous passes without requirement", ok, "", http.StatusOK, "", "ApiKey"},
+		{"invalid key rejected", ok, "sk_invalid", http.StatusUnauthorized, "", `ApiKey error="invalid_token"`},
+		{"valid key", ok, reader, http.StatusOK, "reader", ""},
+		{"anonymous requires identity", RequireIdentity()(ok), "", http.StatusUnauthorized, "", "ApiKey"},
+		{"scope granted", RequireScope("read", "write")(ok), admin, http.StatusOK, "admin", ""},
+		{"scope missing", RequireScope("read", "write")(ok), reader, http.StatusForbidden, "", ""},
+		{"scope anonymous", RequireScope("read")(ok), "", http.StatusUnauthorized, "", "ApiKey"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.key != "" {
+				r.Header.Set(auth.APIKeyHeader, tt.key)
+			}
+			rec := httptest.NewRecorder()
+			Authenticate(keys)(tt.handler).ServeHTTP(rec, r)
+
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if got := rec.Header().Get("X-User"); got != tt.user {
+				t.Fatalf("user = %q, want %q", got, tt.user)
+			}
+			if got := rec.Header().Get("WWW-Authenticate"); got != tt.wwwAuthn {
+				t.Fatalf("WWW-Authenticate = %q, want %q", got, tt.wwwAuthn)
+			}
+		})
+	}
+}
@@ -0,0 +1,412 @@
+package middleware
+
+import (
+	"bufio"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// EncodeWriter 压缩写入器，Flush 用于流式响应及时输出已压缩的数据
+type EncodeWriter interface {
+	io.WriteCloser
+	Flush() error
+}
+
+// Encoder 创建写入 w 的压缩写入器，可通过 CompressOptions.Encoders 接入 zstd、br 等编码
+type Encoder func(w io.Writer) EncodeWriter
+
+// CompressOptions 响应压缩选项，零值字段使用默认值
+type CompressOptions struct {
+	// MinSize 小于该字节数的响应不压缩，默认 1024。处理器在此之前 Flush 时按流式响应直接压缩。
+	MinSize int
+	// Level gzip/deflate 压缩级别，0 表示默认级别
+	Level int
+	// ContentTypes 允许压缩的媒体类型，支持 "text/*" 形式的前缀，默认文本、JSON、JS、XML、SVG 等
+	ContentTypes []string
+	// Encoders 额外的编码器，键为 Accept-Encoding 中的编码名，如 "br"、"zstd"
+	Encoders map[string]Encoder
+	// Preference 客户端权重相同时的编码优先级，默认 zstd、br、gzip、deflate
+	Preference []string
+}
+
+var defaultCompressTypes = []string{
+	"text/*",
+	"application/json",
+	"application/problem+json",
+	"application/javascript",
+	"application/xml",
+	"application/wasm",
+	"application/manifest+json",
+	"image/svg+xml",
+}
+
+// compressor 编译后的压缩配置
+type compressor struct {
+	minSize    int
+	types      []string
+	encoders   map[string]Encoder
+	preference []string
+}
+
+// Compress 按 Accept-Encoding 协商压缩响应。已编码的响应、Range 请求、HEAD 请求和
+// 不在允许列表内的媒体类型原样输出；支持 Flush 的流式响应和协议升级。
+func Compress(opts CompressOptions) func(http.Handler) http.Handler {
+	c := &compressor{
+		minSize:    opts.MinSize,
+		types:      opts.ContentTypes,
+		encoders:   map[string]Encoder{},
+		preference: opts.Preference,
+	}
+	if c.minSize <= 0 {
+		c.minSize = 1024
+	}
+	if len(c.types) == 0 {
+		c.types = defaultCompressTypes
+	}
+	level := opts.Level
+	if level == 0 {
+		level = gzip.DefaultCompression
+	}
+	c.encoders["gzip"] = gzipEncoder(level)
+	c.encoders["deflate"] = deflateEncoder(level)
+	for name, enc := range opts.Encoders {
+		c.encoders[strings.ToLower(name)] = enc
+	}
+	if len(c.preference) == 0 {
+		c.preference = []string{"zstd", "br", "gzip", "deflate"}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			cw := &compressWriter{
+				ResponseWriter: w,
+				c:              c,
+				r:              r,
+				encoding:       c.negotiate(r.Header.Get("Accept-Encoding")),
+				status:         http.StatusOK,
+			}
+			defer cw.close()
+			next.ServeHTTP(cw.expose(w), r)
+		})
+	}
+}
+
+// negotiate 选择客户端可接受且权重最高的编码，没有可用编码时返回空字符串
+func (c *compressor) negotiate(accept string) string {
+	if accept == "" {
+		return ""
+	}
+	q := map[string]float64{}
+	wildcard := -1.0
+	for _, part := range strings.Split(accept, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		weight := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				weight = f
+			}
+		}
+		if name == "*" {
+			wildcard = weight
+			continue
+		}
+		q[name] = weight
+	}
+
+	best, bestQ := "", 0.0
+	consider := func(name string) {
+		if _, ok := c.encoders[name]; !ok {
+			return
+		}
+		w, ok := q[name]
+		if !ok {
+			w = wildcard
+		}
+		if w > bestQ {
+			best, bestQ = name, w
+		}
+	}
+	// 先按优先级遍历，权重相同时保留靠前的编码
+	for _, name := range c.preference {
+		consider(name)
+	}
+	for name := range c.encoders {
+		if !slices.Contains(c.preference, name) {
+			consider(name)
+		}
+	}
+	return best
+}
+
+// typeAllowed 媒体类型是否在允许列表内
+func (c *compressor) typeAllowed(contentType string) bool {
+	mt, _, _ := strings.Cut(contentType, ";")
+	mt = strings.ToLower(strings.TrimSpace(mt))
+	if mt == "" {
+		return false
+	}
+	for _, t := range c.types {
+		if prefix, ok := strings.CutSuffix(t, "*"); ok {
+			if strings.HasPrefix(mt, prefix) {
+				return true
+			}
+		} else if mt == t {
+			return true
+		}
+	}
+	return false
+}
+
+// compressWriter 先缓冲响应开头，达到阈值、Flush 或处理结束时决定是否压缩
+type compressWriter struct {
+	http.ResponseWriter
+	c        *compressor
+	r        *http.Request
+	encoding string
+
+	status      int
+	wroteHeader bool
+	buf         []byte
+	decided     bool
+	flushed     bool
+	hijacked    bool
+	enc         EncodeWriter
+}
+
+func (cw *compressWriter) WriteHeader(code int) {
+	if cw.decided || cw.wroteHeader {
+		if cw.decided && !cw.hijacked && code >= 100 && code < 200 {
+			cw.ResponseWriter.WriteHeader(code)
+		}
+		return
+	}
+	// 1xx 信息响应直接转发，最终响应在决定压缩后写出
+	if code >= 100 && code < 200 && code != http.StatusSwitchingProtocols {
+		cw.ResponseWriter.WriteHeader(code)
+		return
+	}
+	cw.status, cw.wroteHeader = code, true
+	if !bodyAllowed(cw.r, code) {
+		cw.decide()
+	}
+}
+
+func (cw *compressWriter) Write(p []byte) (int, error) {
+	cw.wroteHeader = true
+	if cw.decided {
+		if cw.enc != nil {
+			return cw.enc.Write(p)
+		}
+		return cw.ResponseWriter.Write(p)
+	}
+
+	cw.buf = append(cw.buf, p...)
+	if len(cw.buf) >= cw.c.minSize {
+		if err := cw.decide(); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// expose 按底层 ResponseWriter 实际支持的接口返回包装，与 respwriter 保持一致。
+// 不实现 io.ReaderFrom，以免响应体绕过压缩。
+func (cw *compressWriter) expose(w http.ResponseWriter) http.ResponseWriter {
+	_, isFlusher := w.(http.Flusher)
+	_, isHijacker := w.(http.Hijacker)
+	switch {
+	case isFlusher && isHijacker:
+		return struct {
+			*compressWriter
+			compressFlusher
+			compressHijacker
+		}{cw, compressFlusher{cw}, compressHijacker{cw}}
+	case isFlusher:
+		return struct {
+			*compressWriter
+			compressFlusher
+		}{cw, compressFlusher{cw}}
+	case isHijacker:
+		return struct {
+			*compressWriter
+			compressHijacker
+		}{cw, compressHijacker{cw}}
+	default:
+		return cw
+	}
+}
+
+type compressFlusher struct{ cw *compressWriter }
+
+// Flush 流式响应刷新时立即决定是否压缩，并刷新压缩器和底层连接
+func (f compressFlusher) Flush() {
+	cw := f.cw
+	if cw.hijacked {
+		return
+	}
+	if !cw.decided {
+		cw.flushed = true
+		cw.wroteHeader = true
+		cw.decide()
+	}
+	if cw.enc != nil {
+		cw.enc.Flush()
+	}
+	cw.ResponseWriter.(http.Flusher).Flush()
+}
+
+type compressHijacker struct{ cw *compressWriter }
+
+// Hijack 协议升级时放弃压缩，缓冲区中不应有数据
+func (h compressHijacker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	cw := h.cw
+	conn, brw, err := cw.ResponseWriter.(http.Hijacker).Hijack()
+	if err == nil {
+		cw.hijacked, cw.decided = true, true
+	}
+	return conn, brw, err
+}
+
+// Unwrap 供 http.ResponseController 访问底层 ResponseWriter
+func (cw *compressWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+// decide 写出响应头并输出已缓冲的数据
+func (cw *compressWriter) decide() error {
+	cw.decided = true
+	h := cw.Header()
+
+	if h.Get("Content-Type") == "" && len(cw.buf) > 0 {
+		// 与 net/http 相同的类型探测，必须在压缩前完成
+		h.Set("Content-Type", http.DetectContentType(cw.buf))
+	}
+
+	candidate := bodyAllowed(cw.r, cw.status) &&
+		cw.status != http.StatusPartialContent &&
+		cw.r.Header.Get("Range") == "" &&
+		h.Get("Content-Encoding") == "" &&
+		!strings.Contains(h.Get("Cache-Control"), "no-transform") &&
+		cw.c.typeAllowed(h.Get("Content-Type"))
+	if candidate {
+		// 响应内容随 Accept-Encoding 变化，无论本次是否压缩都要告知缓存
+		addVary(h, "Accept-Encoding")
+	}
+
+	if candidate && cw.encoding != "" && (len(cw.buf) >= cw.c.minSize || cw.flushed) {
+		h.Del("Content-Length")
+		h.Set("Content-Encoding", cw.encoding)
+		if etag := h.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			h.Set("ETag", "W/"+etag)
+		}
+		cw.enc = cw.c.encoders[cw.encoding](cw.ResponseWriter)
+	}
+
+	cw.ResponseWriter.WriteHeader(cw.status)
+	if len(cw.buf) == 0 {
+		return nil
+	}
+	var err error
+	if cw.enc != nil {
+		_, err = cw.enc.Write(cw.buf)
+	} else {
+		_, err = cw.ResponseWriter.Write(cw.buf)
+	}
+	cw.buf = nil
+	return err
+}
+
+// close 处理结束时输出剩余数据并关闭压缩器
+func (cw *compressWriter) close() {
+	if cw.hijacked {
+		return
+	}
+	if !cw.decided {
+		if !cw.wroteHeader {
+			// 处理器没有写出任何内容，交给 net/http 按默认方式结束响应
+			return
+		}
+		cw.decide()
+	}
+	if cw.enc != nil {
+		cw.enc.Close()
+	}
+}
+
+// bodyAllowed 响应是否可以带响应体
+func bodyAllowed(r *http.Request, status int) bool {
+	if r.Method == http.MethodHead {
+		return false
+	}
+	return status >= 200 && status != http.StatusNoContent && status != http.StatusNotModified
+}
+
+// addVary 追加 Vary 值，已存在时不重复添加
+func addVary(h http.Header, value string) {
+	for _, v := range h.Values("Vary") {
+		for _, part := range strings.Split(v, ",") {
+			if p := strings.TrimSpace(part); p == "*" || strings.EqualFold(p, value) {
+				return
+			}
+		}
+	}
+	h.Add("Vary", value)
+}
+
+// pooledWriter 关闭时将压缩器放回池中复用，避免每个响应重新分配压缩状态
+type pooledWriter[T interface {
+	EncodeWriter
+	Reset(io.Writer)
+}] struct {
+	w    T
+	pool *sync.Pool
+}
+
+func (p *pooledWriter[T]) Write(b []byte) (int, error) { return p.w.Write(b) }
+func (p *pooledWriter[T]) Flush() error                { return p.w.Flush() }
+
+func (p *pooledWriter[T]) Close() error {
+	err := p.w.Close()
+	p.w.Reset(io.Discard)
+	p.pool.Put(p.w)
+	return err
+}
+
+func gzipEncoder(level int) Encoder {
+	pool := &sync.Pool{New: func() any {
+		w, err := gzip.NewWriterLevel(io.Discard, level)
+		if err != nil {
+			w = gzip.NewWriter(io.Discard)
+		}
+		return w
+	}}
+	return func(dst io.Writer) EncodeWriter {
+		w := pool.Get().(*gzip.Writer)
+		w.Reset(dst)
+		return &pooledWriter[*gzip.Writer]{w: w, pool: pool}
+	}
+}
+
+// deflateEncoder HTTP 的 deflate 编码是 zlib 格式（RFC 1950），不是裸 deflate 数据
+func deflateEncoder(level int) Encoder {
+	pool := &sync.Pool{New: func() any {
+		w, err := zlib.NewWriterLevel(io.Discard, level)
+		if err != nil {
+			w = zlib.NewWriter(io.Discard)
+		}
+		return w
+	}}
+	return func(dst io.Writer) EncodeWriter {
+		w := pool.Get().(*zlib.Writer)
+		w.Reset(dst)
+		return &pooledWriter[*zlib.Writer]{w: w, pool: pool}
+	}
+}
@@ -0,0 +1,254 @@
+package middleware
+
+import (
+	"bufio"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCompressNegotiate(t *testing.T) {
+	nop := func(w io.Writer) EncodeWriter { return nil }
+	c := &compressor{
+		encoders:   map[string]Encoder{"gzip": nop, "deflate": nop, "br": nop},
+		preference: []string{"zstd", "br", "gzip", "deflate"},
+	}
+
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{"", ""},
+		{"identity", ""},
+		{"gzip", "gzip"},
+		{"GZIP", "gzip"},
+		{"deflate", "deflate"},
+		{"gzip, deflate", "gzip"},
+		{"deflate, gzip", "gzip"},          // 权重相同时按服务端优先级
+		{"gzip;q=0.5, deflate", "deflate"}, // 权重高者优先
+		{"gzip;q=0, deflate;q=0", ""},      // q=0 表示不接受
+		{"gzip, deflate, br", "br"},        // br 优先于 gzip
+		{"br;q=0.1, gzip;q=0.9", "gzip"},   // 客户端权重优先于服务端优先级
+		{"zstd", ""},                       // 未注册的编码
+		{"*", "br"},                        // 通配符匹配优先级最高的已注册编码
+		{"*;q=0.5, gzip", "gzip"},          // 显式列出的编码不受通配符影响
+		{"*, br;q=0", "gzip"},              // 通配符不覆盖显式拒绝
+		{"gzip;q=0, *", "br"},
+		{" gzip ; q=0.8 , deflate ; q=0.9", "deflate"}, // 忽略参数两侧的空格
+	}
+	for _, tt := range tests {
+		if got := c.negotiate(tt.accept); got != tt.want {
+			t.Errorf("negotiate(%q) = %q, want %q", tt.accept, got, tt.want)
+		}
+	}
+}
+
+func TestCompressTypeAllowed(t *testing.T) {
+	c := &compressor{types: defaultCompressTypes}
+	tests := []struct {
+		contentType string
+		want        bool
+	}{
+		{"text/html; charset=utf-8", true},
+		{"TEXT/CSS", true},
+		{"application/json", true},
+		{"application/problem+json", true},
+		{"image/svg+xml", true},
+		{"application/jsonp", false},
+		{"image/png", false},
+		{"application/octet-stream", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := c.typeAllowed(tt.contentType); got != tt.want {
+			t.Errorf("typeAllowed(%q) = %v, want %v", tt.contentType, got, tt.want)
+		}
+	}
+}
+
+func TestCompressRoundTrip(t *testing.T) {
+	body := strings.Repeat(`{"msg":"hello"}`, 200)
+	h := Compress(CompressOptions{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, body)
+	}))
+
+	tests := []struct {
+		encoding string
+		reader   func(io.Reader) (io.Reader, error)
+	}{
+		{"gzip", func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) }},
+		// HTTP 的 deflate 编码是 zlib 格式
+		{"deflate", func(r io.Reader) (io.Reader, error) { return zlib.NewReader(r) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.encoding, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Accept-Encoding", tt.encoding)
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if got := rec.Header().Get("Content-Encoding"); got != tt.encoding {
+				t.Fatalf("Content-Encoding = %q, want %q", got, tt.encoding)
+			}
+			if got := rec.Header().Get("Vary"); got != "Accept-Encoding" {
+				t.Fatalf("Vary = %q", got)
+			}
+			r, err := tt.reader(rec.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != body {
+				t.Fatal("decoded body mismatch")
+			}
+		})
+	}
+}
+
+func TestCompressSkip(t *testing.T) {
+	large := strings.Repeat("a", 2048)
+	tests := []struct {
+		name    string
+		method  string
+		reqHdr  map[string]string
+		respHdr map[string]string
+		status  int
+		body    string
+	}{
+		{"below min size", "GET", nil, map[string]string{"Content-Type": "text/plain"}, 200, "small"},
+		{"range request", "GET", map[string]string{"Range": "bytes=0-9"}, map[string]string{"Content-Type": "text/plain"}, 206, large},
+		{"already encoded", "GET", nil, map[string]string{"Content-Type": "text/plain", "Content-Encoding": "gzip"}, 200, large},
+		{"not allowed type", "GET", nil, map[string]string{"Content-Type": "image/png"}, 200, large},
+		{"no-transform", "GET", nil, map[string]string{"Content-Type": "text/plain", "Cache-Control": "no-transform"}, 200, large},
+		{"head", "HEAD", nil, map[string]string{"Content-Type": "text/plain"}, 200, ""},
+		{"not modified", "GET", nil, nil, 304, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := Compress(CompressOptions{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				for k, v := range tt.respHdr {
+					w.Header().Set(k, v)
+				}
+				w.WriteHeader(tt.status)
+				io.WriteString(w, tt.body)
+			}))
+			req := httptest.NewRequest(tt.method, "/", nil)
+			req.Header.Set("Accept-Encoding", "gzip")
+			for k, v := range tt.reqHdr {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if got, want := rec.Header().Get("Content-Encoding"), tt.respHdr["Content-Encoding"]; got != want {
+				t.Fatalf("Content-Encoding = %q, want %q", got, want)
+			}
+			if rec.Body.String() != tt.body {
+				t.Fatal("body was modified")
+			}
+		})
+	}
+}
+
+func TestCompressStreaming(t *testing.T) {
+	flushed := make(chan struct{})
+	h := Compress(CompressOptions{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, "data: 1\n\n")
+		http.NewResponseController(w).Flush()
+		<-flushed
+		io.WriteString(w, "data: 2\n\n")
+	}))
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	resp, err := http.DefaultTransport.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.Header.Get("Content-Encoding") != "gzip" {
+		t.Fatalf("Content-Encoding = %q", resp.Header.Get("Content-Encoding"))
+	}
+
+	// 第一个事件在处理器结束前即可解码
+	zr, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := make([]byte, len("data: 1\n\n"))
+	if _, err := io.ReadFull(zr, first); err != nil {
+		t.Fatal(err)
+	}
+	if string(first) != "data: 1\n\n" {
+		t.Fatalf("first event = %q", first)
+	}
+	close(flushed)
+	rest, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(rest) != "data: 2\n\n" {
+		t.Fatalf("rest = %q", rest)
+	}
+}
+
+// plainWriter 只实现 http.ResponseWriter
+type plainWriter struct{ http.ResponseWriter }
+
+// hijackWriter 支持 Flush 和 Hijack
+type hijackWriter struct{ *httptest.ResponseRecorder }
+
+func (hijackWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) { return nil, nil, nil }
+
+func TestCompressExposedInterfaces(t *testing.T) {
+	tests := []struct {
+		name            string
+		w               http.ResponseWriter
+		flusher, hijack bool
+	}{
+		{"plain", plainWriter{httptest.NewRecorder()}, false, false},
+		{"flusher", httptest.NewRecorder(), true, false},
+		{"flusher and hijacker", hijackWriter{httptest.NewRecorder()}, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var flusher, hijacker bool
+			var flushErr error
+			h := Compress(CompressOptions{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, flusher = w.(http.Flusher)
+				_, hijacker = w.(http.Hijacker)
+				_, readerFrom := w.(io.ReaderFrom)
+				if readerFrom {
+					t.Error("io.ReaderFrom would bypass compression")
+				}
+				flushErr = http.NewResponseController(w).Flush()
+			}))
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Accept-Encoding", "gzip")
+			h.ServeHTTP(tt.w, req)
+
+			if flusher != tt.flusher || hijacker != tt.hijack {
+				t.Fatalf("Flusher = %v, Hijacker = %v, want %v, %v", flusher, hijacker, tt.flusher, tt.hijack)
+			}
+			// 底层不支持 Flush 时 ResponseController 应返回错误，而不是静默成功
+			if (flushErr == nil) != tt.flusher {
+				t.Fatalf("Flush error = %v", flushErr)
+			}
+		})
+	}
+}
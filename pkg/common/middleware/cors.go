@@ -1,22 +1,264 @@
 // middleware/cors.go
 package middleware
 
-import "net/http"
-
-func CorsMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// 设置 CORS 头
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-
-		// 处理 OPTIONS 请求
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
-			return
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// CORSOptions 跨域策略配置
+type CORSOptions struct {
+	// AllowedOrigins 允许的来源：精确值 "https://app.example.com"、
+	// 通配单级子域名 "https://*.example.com"、正则 "regex:https://[a-z]+\.example\.org"（需匹配整个来源），
+	// 或 "*" 允许全部
+	AllowedOrigins []string
+	// AllowedMethods 预检允许的方法，默认 GET、HEAD、POST、PUT、PATCH、DELETE
+	AllowedMethods []string
+	// AllowedHeaders 预检允许的请求头，"*" 表示允许客户端请求的任意头，默认 Content-Type、Authorization、X-Request-ID
+	AllowedHeaders []string
+	// ExposedHeaders 允许浏览器脚本读取的响应头
+	ExposedHeaders []string
+	// AllowCredentials 是否允许携带 Cookie 等凭据，不能与 "*" 同时使用
+	AllowCredentials bool
+	// MaxAge 预检结果缓存秒数，0 表示不设置
+	MaxAge int
+}
+
+// CORSPolicy 编译后的跨域策略
+type CORSPolicy struct {
+	allowAll    bool
+	exact       map[string]bool
+	wildcards   [][2]string // 前缀、后缀
+	patterns    []*regexp.Regexp
+	methods     map[string]bool
+	headers     map[string]bool
+	anyHeader   bool
+	credentials bool
+
+	allowMethods  string
+	allowHeaders  string
+	exposeHeaders string
+	maxAge        string
+}
+
+// NewCORSPolicy 校验并编译跨域策略，AllowedOrigins 为空时不允许任何跨域请求
+func NewCORSPolicy(opts CORSOptions) (*CORSPolicy, error) {
+	p := &CORSPolicy{
+		exact:       map[string]bool{},
+		methods:     map[string]bool{},
+		headers:     map[string]bool{},
+		credentials: opts.AllowCredentials,
+	}
+
+	for _, o := range opts.AllowedOrigins {
+		switch {
+		case o == "*":
+			p.allowAll = true
+		case strings.HasPrefix(o, "regex:"):
+			// 锚定整个来源，避免 "https://a.example.org.evil.com" 之类的部分匹配
+			re, err := regexp.Compile(`^(?:` + strings.TrimPrefix(o, "regex:") + `)$`)
+			if err != nil {
+				return nil, fmt.Errorf("cors: invalid origin pattern %q: %w", o, err)
+			}
+			p.patterns = append(p.patterns, re)
+		case strings.Count(o, "*") == 1:
+			prefix, suffix, _ := strings.Cut(strings.ToLower(o), "*")
+			if !strings.HasSuffix(prefix, "://") && !strings.HasSuffix(prefix, ".") {
+				return nil, fmt.Errorf("cors: wildcard must replace a whole subdomain: %q", o)
+			}
+			p.wildcards = append(p.wildcards, [2]string{prefix, suffix})
+		case strings.Contains(o, "*"):
+			return nil, fmt.Errorf("cors: invalid origin %q", o)
+		default:
+			p.exact[strings.ToLower(strings.TrimSuffix(o, "/"))] = true
+		}
+	}
+
+	if p.allowAll && p.credentials {
+		// 否则任意网站都能读取带凭据的响应，Fetch 规范禁止 "*" 与凭据同时使用也是这个原因
+		return nil, errors.New(`cors: allowed origin "*" cannot be used with allow credentials, list the origins explicitly`)
+	}
+
+	methods := append([]string(nil), opts.AllowedMethods...)
+	if len(methods) == 0 {
+		methods = []string{http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+	}
+	for i, m := range methods {
+		methods[i] = strings.ToUpper(m)
+		p.methods[methods[i]] = true
+	}
+	p.allowMethods = strings.Join(methods, ", ")
+
+	headers := opts.AllowedHeaders
+	if len(headers) == 0 {
+		headers = []string{"Content-Type", "Authorization", "X-Request-ID"}
+	}
+	for _, h := range headers {
+		if h == "*" {
+			p.anyHeader = true
+			continue
+		}
+		p.headers[http.CanonicalHeaderKey(h)] = true
+	}
+	p.allowHeaders = strings.Join(headers, ", ")
+
+	p.exposeHeaders = strings.Join(opts.ExposedHeaders, ", ")
+	if opts.MaxAge > 0 {
+		p.maxAge = strconv.Itoa(opts.MaxAge)
+	}
+	return p, nil
+}
+
+// MustCORSPolicy 同 NewCORSPolicy，配置错误时 panic，用于代码中写死的策略
+func MustCORSPolicy(opts CORSOptions) *CORSPolicy {
+	p, err := NewCORSPolicy(opts)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// allowOrigin 来源是否在允许列表内
+func (p *CORSPolicy) allowOrigin(origin string) bool {
+	if p.allowAll {
+		return true
+	}
+	o := strings.ToLower(origin)
+	if p.exact[o] {
+		return true
+	}
+	for _, wc := range p.wildcards {
+		if len(o) > len(wc[0])+len(wc[1]) && strings.HasPrefix(o, wc[0]) && strings.HasSuffix(o, wc[1]) {
+			// 通配符只匹配一级子域名
+			if sub := o[len(wc[0]) : len(o)-len(wc[1])]; !strings.ContainsAny(sub, "/:.@") {
+				return true
+			}
+		}
+	}
+	for _, re := range p.patterns {
+		if re.MatchString(origin) {
+			return true
 		}
+	}
+	return false
+}
+
+// IsPreflight 判断是否为 CORS 预检请求：OPTIONS 且带 Origin 和 Access-Control-Request-Method
+func IsPreflight(r *http.Request) bool {
+	return r.Method == http.MethodOptions &&
+		r.Header.Get("Origin") != "" &&
+		r.Header.Get("Access-Control-Request-Method") != ""
+}
+
+// CorsMiddleware 按请求匹配的策略处理跨域。policy 返回请求所属路由的策略，返回 nil 时使用 def；
+// 两者均为 nil 时不处理跨域。非 CORS 的 OPTIONS 请求交给后续处理器。
+func CorsMiddleware(def *CORSPolicy, policy func(*http.Request) *CORSPolicy) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			p := def
+			if policy != nil {
+				if rp := policy(r); rp != nil {
+					p = rp
+				}
+			}
+			if p == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if IsPreflight(r) {
+				p.preflight(w, r)
+				return
+			}
+			p.actual(w, r)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// setOrigin 写入 Allow-Origin 和 Allow-Credentials，来源不允许时返回 false
+func (p *CORSPolicy) setOrigin(h http.Header, origin string) bool {
+	if !p.allowOrigin(origin) {
+		return false
+	}
+	if p.allowAll {
+		h.Set("Access-Control-Allow-Origin", "*")
+		return true
+	}
+	h.Set("Access-Control-Allow-Origin", origin)
+	if p.credentials {
+		h.Set("Access-Control-Allow-Credentials", "true")
+	}
+	return true
+}
+
+// varyOrigin 响应随来源变化时需告知缓存
+func (p *CORSPolicy) varyOrigin() bool {
+	return !p.allowAll
+}
+
+func (p *CORSPolicy) actual(w http.ResponseWriter, r *http.Request) {
+	h := w.Header()
+	if p.varyOrigin() {
+		h.Add("Vary", "Origin")
+	}
+	origin := r.Header.Get("Origin")
+	if origin == "" || !p.setOrigin(h, origin) {
+		return
+	}
+	if p.exposeHeaders != "" {
+		h.Set("Access-Control-Expose-Headers", p.exposeHeaders)
+	}
+}
 
-		// 调用下一个处理器
-		next.ServeHTTP(w, r)
-	})
+// preflight 应答预检请求，不允许时不返回 CORS 头，由浏览器拒绝实际请求
+func (p *CORSPolicy) preflight(w http.ResponseWriter, r *http.Request) {
+	h := w.Header()
+	h.Add("Vary", "Origin")
+	h.Add("Vary", "Access-Control-Request-Method")
+	h.Add("Vary", "Access-Control-Request-Headers")
+	defer w.WriteHeader(http.StatusNoContent)
+
+	method := strings.ToUpper(r.Header.Get("Access-Control-Request-Method"))
+	if !p.methods[method] {
+		return
+	}
+	reqHeaders := requestedHeaders(r)
+	if !p.anyHeader {
+		for _, name := range reqHeaders {
+			if !p.headers[http.CanonicalHeaderKey(name)] {
+				return
+			}
+		}
+	}
+	if !p.setOrigin(h, r.Header.Get("Origin")) {
+		return
+	}
+
+	h.Set("Access-Control-Allow-Methods", p.allowMethods)
+	switch {
+	case p.anyHeader && len(reqHeaders) > 0:
+		h.Set("Access-Control-Allow-Headers", strings.Join(reqHeaders, ", "))
+	case !p.anyHeader:
+		h.Set("Access-Control-Allow-Headers", p.allowHeaders)
+	}
+	if p.maxAge != "" {
+		h.Set("Access-Control-Max-Age", p.maxAge)
+	}
+}
+
+func requestedHeaders(r *http.Request) []string {
+	var out []string
+	for _, v := range r.Header.Values("Access-Control-Request-Headers") {
+		for _, name := range strings.Split(v, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				out = append(out, name)
+			}
+		}
+	}
+	return out
 }
@@ -0,0 +1,167 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCORSAllowOrigin(t *testing.T) {
+	tests := []struct {
+		name    string
+		allowed []string
+		origin  string
+		want    bool
+	}{
+		{"exact", []string{"https://app.example.com"}, "https://app.example.com", true},
+		{"exact case insensitive", []string{"https://App.Example.com/"}, "https://app.example.com", true},
+		{"exact other scheme", []string{"https://app.example.com"}, "http://app.example.com", false},
+		{"exact other port", []string{"https://app.example.com"}, "https://app.example.com:8443", false},
+		{"exact suffix attack", []string{"https://app.example.com"}, "https://app.example.com.evil.com", false},
+
+		{"wildcard subdomain", []string{"https://*.example.com"}, "https://app.example.com", true},
+		{"wildcard bare domain", []string{"https://*.example.com"}, "https://example.com", false},
+		{"wildcard empty label", []string{"https://*.example.com"}, "https://.example.com", false},
+		{"wildcard nested label", []string{"https://*.example.com"}, "https://a.b.example.com", false},
+		{"wildcard evil prefix", []string{"https://*.example.com"}, "https://evil.com.example.com", false},
+		{"wildcard lookalike domain", []string{"https://*.example.com"}, "https://evilexample.com", false},
+		{"wildcard suffix attack", []string{"https://*.example.com"}, "https://app.example.com.evil.com", false},
+		{"wildcard other scheme", []string{"https://*.example.com"}, "http://app.example.com", false},
+		{"wildcard port smuggling", []string{"https://*.example.com"}, "https://evil.com:443.example.com", false},
+		{"wildcard userinfo", []string{"https://*.example.com"}, "https://evil.com@app.example.com", false},
+		{"wildcard with port", []string{"http://*.example.com:8080"}, "http://dev.example.com:8080", true},
+		{"wildcard with port mismatch", []string{"http://*.example.com:8080"}, "http://dev.example.com:9090", false},
+
+		{"regex", []string{`regex:https://[a-z]+\.example\.org`}, "https://app.example.org", true},
+		{"regex anchored end", []string{`regex:https://[a-z]+\.example\.org`}, "https://app.example.org.evil.com", false},
+		{"regex anchored start", []string{`regex:https://[a-z]+\.example\.org`}, "https://evil.com/https://app.example.org", false},
+		{"regex explicit anchors", []string{`regex:^https://[a-z]+\.example\.org$`}, "https://app.example.org", true},
+		{"regex alternation anchored", []string{`regex:https://a\.example\.org|https://b\.example\.org`}, "https://b.example.org.evil.com", false},
+
+		{"any", []string{"*"}, "https://anything.test", true},
+		{"none configured", nil, "https://app.example.com", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewCORSPolicy(CORSOptions{AllowedOrigins: tt.allowed})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := p.allowOrigin(tt.origin); got != tt.want {
+				t.Fatalf("allowOrigin(%q) with %q = %v, want %v", tt.origin, tt.allowed, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewCORSPolicyInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		opts CORSOptions
+	}{
+		{"any origin with credentials", CORSOptions{AllowedOrigins: []string{"*"}, AllowCredentials: true}},
+		{"partial label wildcard", CORSOptions{AllowedOrigins: []string{"https://app*.example.com"}}},
+		{"multiple wildcards", CORSOptions{AllowedOrigins: []string{"https://*.*.example.com"}}},
+		{"bad regex", CORSOptions{AllowedOrigins: []string{"regex:("}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewCORSPolicy(tt.opts); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
+
+func TestCORSMiddleware(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	tests := []struct {
+		name        string
+		opts        CORSOptions
+		method      string
+		reqHeaders  map[string]string
+		status      int
+		allowOrigin string
+		credentials string
+		allowMethod string
+	}{
+		{
+			name:        "actual allowed with credentials echoes origin",
+			opts:        CORSOptions{AllowedOrigins: []string{"https://app.example.com"}, AllowCredentials: true},
+			method:      http.MethodGet,
+			reqHeaders:  map[string]string{"Origin": "https://app.example.com"},
+			status:      http.StatusOK,
+			allowOrigin: "https://app.example.com",
+			credentials: "true",
+		},
+		{
+			name:       "actual rejected origin",
+			opts:       CORSOptions{AllowedOrigins: []string{"https://app.example.com"}, AllowCredentials: true},
+			method:     http.MethodGet,
+			reqHeaders: map[string]string{"Origin": "https://evil.com"},
+			status:     http.StatusOK,
+		},
+		{
+			name:        "any origin without credentials",
+			opts:        CORSOptions{AllowedOrigins: []string{"*"}},
+			method:      http.MethodGet,
+			reqHeaders:  map[string]string{"Origin": "https://evil.com"},
+			status:      http.StatusOK,
+			allowOrigin: "*",
+		},
+		{
+			name:        "preflight allowed",
+			opts:        CORSOptions{AllowedOrigins: []string{"https://*.example.com"}},
+			method:      http.MethodOptions,
+			reqHeaders:  map[string]string{"Origin": "https://app.example.com", "Access-Control-Request-Method": "PUT", "Access-Control-Request-Headers": "content-type"},
+			status:      http.StatusNoContent,
+			allowOrigin: "https://app.example.com",
+			allowMethod: "GET, HEAD, POST, PUT, PATCH, DELETE",
+		},
+		{
+			name:       "preflight method not allowed",
+			opts:       CORSOptions{AllowedOrigins: []string{"https://app.example.com"}, AllowedMethods: []string{"GET"}},
+			method:     http.MethodOptions,
+			reqHeaders: map[string]string{"Origin": "https://app.example.com", "Access-Control-Request-Method": "DELETE"},
+			status:     http.StatusNoContent,
+		},
+		{
+			name:       "preflight header not allowed",
+			opts:       CORSOptions{AllowedOrigins: []string{"https://app.example.com"}},
+			method:     http.MethodOptions,
+			reqHeaders: map[string]string{"Origin": "https://app.example.com", "Access-Control-Request-Method": "GET", "Access-Control-Request-Headers": "X-Secret"},
+			status:     http.StatusNoContent,
+		},
+		{
+			name:       "preflight evil subdomain",
+			opts:       CORSOptions{AllowedOrigins: []string{"https://*.example.com"}},
+			method:     http.MethodOptions,
+			reqHeaders: map[string]string{"Origin": "https://evil.com.example.com", "Access-Control-Request-Method": "GET"},
+			status:     http.StatusNoContent,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := CorsMiddleware(MustCORSPolicy(tt.opts), nil)(next)
+			r := httptest.NewRequest(tt.method, "/", nil)
+			for k, v := range tt.reqHeaders {
+				r.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, r)
+
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if got := rec.Header().Get("Access-Control-Allow-Origin"); got != tt.allowOrigin {
+				t.Fatalf("Allow-Origin = %q, want %q", got, tt.allowOrigin)
+			}
+			if got := rec.Header().Get("Access-Control-Allow-Credentials"); got != tt.credentials {
+				t.Fatalf("Allow-Credentials = %q, want %q", got, tt.credentials)
+			}
+			if got := rec.Header().Get("Access-Control-Allow-Methods"); got != tt.allowMethod {
+				t.Fatalf("Allow-Methods = %q, want %q", got, tt.allowMethod)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"net/http"
+	"net/netip"
+	"scaffold/pkg/auth"
+	"scaffold/pkg/logger"
+)
+
+// LogContextMiddleware 将请求方法、路径、客户端 IP 和调用方身份写入请求上下文，
+// 处理器中使用 logger.FromContext(ctx) 或 slog.InfoContext(ctx, ...) 记录的日志会自动带上这些属性。
+// 需放在设置身份的中间件（如 ClientCertMiddleware）之后、LoggingMiddleware 之前。
+// 客户端 IP 只使用连接的对端地址，不信任 X-Forwarded-For。
+func LogContextMiddleware(next http.Handler) http.Handler {
+	return LogContext(nil)(next)
+}
+
+// LogContext 同 LogContextMiddleware，对端地址属于 trusted 时按 ClientIP 从 X-Forwarded-For 中取客户端 IP，
+// 与 IPKey 使用同一地址
+func LogContext(trusted []netip.Prefix) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			clientIP := r.RemoteAddr
+			if ip := ClientIP(r, trusted); ip.IsValid() {
+				clientIP = ip.String()
+			}
+			args := []any{
+				"method", r.Method,
+				"path", r.URL.Path,
+				"client_ip", clientIP,
+			}
+			if id, ok := auth.FromContext(r.Context()); ok {
+				args = append(args, "user", id.Name)
+			}
+			ctx := logger.AppendContext(r.Context(), args...)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
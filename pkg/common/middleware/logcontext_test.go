@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"scaffold/pkg/logger"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestLogContextClientIP 日志中的客户端 IP 与限流键使用同一解析结果，不可信来源的 X-Forwarded-For 被忽略
+func TestLogContextClientIP(t *testing.T) {
+	trusted := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+	tests := []struct {
+		name       string
+		remoteAddr string
+		xff        string
+		want       string
+	}{
+		{"direct", "203.0.113.7:4000", "", "203.0.113.7"},
+		{"spoofed from untrusted peer", "203.0.113.7:4000", "1.2.3.4", "203.0.113.7"},
+		{"trusted proxy", "10.0.0.1:4000", "1.2.3.4, 198.51.100.9", "198.51.100.9"},
+		{"trusted proxy chain", "10.0.0.1:4000", "198.51.100.9, 10.0.0.2", "198.51.100.9"},
+		{"unix socket", "@", "1.2.3.4", "@"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			h := LogContext(trusted)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				logger.FromContext(r.Context()).Info("hello")
+			}))
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			if tt.xff != "" {
+				r.Header.Set("X-Forwarded-For", tt.xff)
+			}
+			r.Header.Set("X-Real-IP", "9.9.9.9")
+			r = r.WithContext(logger.NewContext(r.Context(), slog.New(slog.NewTextHandler(&buf, nil))))
+			h.ServeHTTP(httptest.NewRecorder(), r)
+
+			if !slices.Contains(strings.Fields(buf.String()), "client_ip="+tt.want) {
+				t.Fatalf("log = %s, want client_ip=%s", buf.String(), tt.want)
+			}
+			if key := IPKey(trusted)(r); key != "ip:"+tt.want {
+				t.Fatalf("rate limit key = %q, want ip:%s", key, tt.want)
+			}
+		})
+	}
+}
@@ -3,8 +3,8 @@ package middleware
 import (
 	"bytes"
 	"io"
-	"net"
 	"net/http"
+	"scaffold/pkg/common/respwriter"
 	"scaffold/pkg/logger"
 	"strings"
 	"time"
@@ -16,22 +16,21 @@ const (
 
 func LoggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// 跳过前端资源请求
+		// 跳过前端资源请求和探针请求
 		if strings.EqualFold(r.URL.Path, "/") ||
 			strings.HasPrefix(r.URL.Path, "/static") ||
-			strings.HasPrefix(r.URL.Path, "/favicon.ico") {
+			strings.HasPrefix(r.URL.Path, "/favicon.ico") ||
+			r.URL.Path == "/healthz" || r.URL.Path == "/readyz" ||
+			r.URL.Path == "/metrics" {
 			next.ServeHTTP(w, r)
 			return
 		}
 
-		// 获取客户端 IP
-		clientIP := getClientIP(r)
+		// 请求方法、路径、客户端 IP 等由 LogContextMiddleware 写入上下文，随日志自动输出
+		log := logger.FromContext(r.Context()).With("prefix", "HTTP")
 
 		// 请求开始时打印日志
-		logger.WithPrefix("HTTP").Info("request started",
-			"method", r.Method,
-			"path", r.URL.Path,
-			"remote_ip", clientIP)
+		log.Info("request started")
 
 		start := time.Now()
 
@@ -48,18 +47,24 @@ func LoggingMiddleware(next http.Handler) http.Handler {
 		// 设置新的 Body
 		newRequest.Body = io.NopCloser(bodyReader)
 
-		wrappedWriter := &responseWriter{ResponseWriter: w, status: 200}
+		// 状态码是错误码（>= 400）时记录响应体以便后续打印
+		var respBuffer bytes.Buffer
+		wrappedWriter := respwriter.Wrap(w)
+		wrappedWriter.OnWrite(func(status int, p []byte) {
+			if status >= http.StatusBadRequest && respBuffer.Len() <= maxLogSize {
+				respBuffer.Write(p)
+			}
+		})
 		next.ServeHTTP(wrappedWriter, &newRequest)
 
 		duration := time.Since(start)
 
 		// 默认日志参数
 		logArgs := []any{
-			"method", r.Method,
-			"path", r.URL.Path,
-			"status", wrappedWriter.status,
+			"status", wrappedWriter.Status(),
+			"bytes", wrappedWriter.Written(),
 			"duration", duration,
-			"remote_ip", clientIP,
+			"ttfb", wrappedWriter.TimeToFirstByte(),
 		}
 
 		// 如果请求体不为空，记录请求体
@@ -72,8 +77,8 @@ func LoggingMiddleware(next http.Handler) http.Handler {
 		}
 
 		// 如果状态码大于等于 400，记录响应体（错误信息）
-		if wrappedWriter.status >= http.StatusBadRequest && wrappedWriter.body.Len() > 0 {
-			respBody := wrappedWriter.body.String()
+		if respBuffer.Len() > 0 {
+			respBody := respBuffer.String()
 			if len(respBody) > maxLogSize {
 				respBody = respBody[:maxLogSize] + "... (truncated)"
 			}
@@ -81,42 +86,6 @@ func LoggingMiddleware(next http.Handler) http.Handler {
 		}
 
 		// 请求结束时打印日志
-		logger.WithPrefix("HTTP").Info("request completed", logArgs...)
+		log.Info("request completed", logArgs...)
 	})
 }
-
-type responseWriter struct {
-	http.ResponseWriter
-	status int
-	body   bytes.Buffer
-}
-
-func (rw *responseWriter) WriteHeader(code int) {
-	rw.status = code
-	rw.ResponseWriter.WriteHeader(code)
-}
-
-func (rw *responseWriter) Write(b []byte) (int, error) {
-	// 如果状态码是错误码（>= 400），我们将内容写入 buffer 以便后续打印
-	if rw.status >= http.StatusBadRequest {
-		rw.body.Write(b)
-	}
-	return rw.ResponseWriter.Write(b)
-}
-
-// getClientIP 尝试获取客户端的真实 IP 地址
-func getClientIP(r *http.Request) string {
-	// 检查常见的 HTTP 头部
-	if ip := r.Header.Get("X-Forwarded-For"); ip != "" {
-		return strings.Split(ip, ",")[0]
-	}
-	if ip := r.Header.Get("X-Real-IP"); ip != "" {
-		return ip
-	}
-	// 如果没有找到代理头部，就使用 RemoteAddr
-	ip, _, err := net.SplitHostPort(r.RemoteAddr)
-	if err != nil {
-		return r.RemoteAddr
-	}
-	return ip
-}
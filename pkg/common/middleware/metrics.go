@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"net/http"
+	"scaffold/pkg/common/respwriter"
+	"scaffold/pkg/metrics"
+	"strconv"
+	"time"
+)
+
+var (
+	httpRequestsTotal = metrics.NewCounter("http_requests_total",
+		"Total number of HTTP requests.", "method", "route", "code")
+	httpRequestDuration = metrics.NewHistogram("http_request_duration_seconds",
+		"HTTP request latency in seconds.", nil, "method", "route")
+	httpRequestsInFlight = metrics.NewGauge("http_requests_in_flight",
+		"Number of HTTP requests currently being served.", "route")
+)
+
+// MetricsMiddleware 统计请求数、延迟和并发数。route 返回请求匹配的路由模式，
+// 用作标签以避免原始路径导致的标签爆炸。
+func MetricsMiddleware(route func(*http.Request) string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			pattern := route(r)
+
+			httpRequestsInFlight.Inc(pattern)
+			defer httpRequestsInFlight.Dec(pattern)
+
+			start := time.Now()
+			wrappedWriter := respwriter.Wrap(w)
+			next.ServeHTTP(wrappedWriter, r)
+
+			method := metricMethod(r.Method)
+			httpRequestsTotal.Inc(method, pattern, strconv.Itoa(wrappedWriter.Status()))
+			httpRequestDuration.Observe(time.Since(start).Seconds(), method, pattern)
+		})
+	}
+}
+
+// metricMethod 非标准方法统一记为 OTHER，避免客户端构造任意方法导致标签爆炸
+func metricMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	}
+	return "OTHER"
+}
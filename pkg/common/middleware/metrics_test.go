@@ -0,0 +1,22 @@
+package middleware
+
+import "testing"
+
+func TestMetricMethod(t *testing.T) {
+	tests := []struct {
+		method, want string
+	}{
+		{"GET", "GET"},
+		{"DELETE", "DELETE"},
+		{"OPTIONS", "OPTIONS"},
+		{"get", "OTHER"},
+		{"AAA", "OTHER"},
+		{"PROPFIND", "OTHER"},
+		{"", "OTHER"},
+	}
+	for _, tt := range tests {
+		if got := metricMethod(tt.method); got != tt.want {
+			t.Errorf("metricMethod(%q) = %q, want %q", tt.method, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"net/http"
+	"scaffold/pkg/auth"
+	"scaffold/pkg/common/problem"
+)
+
+// ClientCertMiddleware 将已验证的客户端证书转换为身份并写入请求上下文
+func ClientCertMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// 只信任经过 CA 校验的证书链
+		if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 && len(r.TLS.VerifiedChains[0]) > 0 {
+			id := auth.FromCertificate(r.TLS.VerifiedChains[0][0])
+			r = r.WithContext(auth.WithIdentity(r.Context(), id))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequireIdentity 要求请求已认证，names 非空时身份须匹配其中之一
+func RequireIdentity(names ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id, ok := auth.FromContext(r.Context())
+			if !ok {
+				problem.Error(w, r, http.StatusUnauthorized, "authentication required")
+				return
+			}
+			if len(names) > 0 && !matchesAny(id, names) {
+				problem.Error(w, r, http.StatusForbidden, "identity not allowed")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func matchesAny(id *auth.Identity, names []string) bool {
+	for _, n := range names {
+		if id.Matches(n) {
+			return true
+		}
+	}
+	return false
+}
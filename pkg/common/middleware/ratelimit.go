@@ -0,0 +1,163 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"net/netip"
+	"scaffold/pkg/auth"
+	"scaffold/pkg/common/problem"
+	"scaffold/pkg/common/respwriter"
+	"scaffold/pkg/metrics"
+	"scaffold/pkg/ratelimit"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var httpRateLimited = metrics.NewCounter("http_rate_limited_total",
+	"Total number of HTTP requests rejected by rate limiting.", "route")
+
+// RateLimitKey 返回限流使用的键
+type RateLimitKey func(*http.Request) string
+
+// KeyByIP 按客户端 IP 限流，只使用连接的对端地址，不信任 X-Forwarded-For
+func KeyByIP(r *http.Request) string {
+	return IPKey(nil)(r)
+}
+
+// IPKey 按客户端 IP 限流，对端地址属于 trusted 时才从 X-Forwarded-For 中取客户端地址。
+// IPv6 地址按 /64 归并，避免同一网段轮换地址绕过限流。
+func IPKey(trusted []netip.Prefix) RateLimitKey {
+	return func(r *http.Request) string {
+		ip := ClientIP(r, trusted)
+		if !ip.IsValid() {
+			return "ip:" + r.RemoteAddr
+		}
+		if ip.Is6() {
+			p, _ := ip.Prefix(64)
+			return "ip:" + p.String()
+		}
+		return "ip:" + ip.String()
+	}
+}
+
+// ClientIP 返回客户端 IP。对端地址属于可信代理时，从右向左跳过 X-Forwarded-For 中的可信代理，
+// 取第一个不可信的地址；客户端可以在最左侧伪造任意值，因此不能直接取第一个。
+func ClientIP(r *http.Request, trusted []netip.Prefix) netip.Addr {
+	ip := remoteIP(r)
+	if !ip.IsValid() || !containsIP(trusted, ip) {
+		return ip
+	}
+
+	hops := r.Header.Values("X-Forwarded-For")
+	for i := len(hops) - 1; i >= 0; i-- {
+		parts := strings.Split(hops[i], ",")
+		for j := len(parts) - 1; j >= 0; j-- {
+			hop, err := netip.ParseAddr(strings.TrimSpace(parts[j]))
+			if err != nil {
+				return ip
+			}
+			ip = hop.Unmap()
+			if !containsIP(trusted, ip) {
+				return ip
+			}
+		}
+	}
+	return ip
+}
+
+func remoteIP(r *http.Request) netip.Addr {
+	ap, err := netip.ParseAddrPort(r.RemoteAddr)
+	if err != nil {
+		return netip.Addr{}
+	}
+	return ap.Addr().Unmap()
+}
+
+func containsIP(prefixes []netip.Prefix, ip netip.Addr) bool {
+	for _, p := range prefixes {
+		if p.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// KeyByIdentity 按已认证的调用方（API Key 名称、JWT 主体等）限流，匿名请求按客户端 IP。
+// 需放在认证中间件之后。
+func KeyByIdentity(r *http.Request) string {
+	return IdentityKey(KeyByIP)(r)
+}
+
+// IdentityKey 按已认证的调用方限流，匿名请求使用 fallback
+func IdentityKey(fallback RateLimitKey) RateLimitKey {
+	return func(r *http.Request) string {
+		if id, ok := auth.FromContext(r.Context()); ok && id.Name != "" {
+			return id.Method + ":" + id.Name
+		}
+		return fallback(r)
+	}
+}
+
+// KeyByRoute 按路由模式限流，所有调用方共享同一路由的配额
+func KeyByRoute(r *http.Request) string {
+	if r.Pattern != "" {
+		return "route:" + r.Pattern
+	}
+	return "route:" + r.URL.Path
+}
+
+// RateLimit 令牌桶限流，key 为 nil 时按客户端 IP。每个响应都带 RateLimit-Limit、
+// RateLimit-Remaining、RateLimit-Reset 头，超限时返回 429 和 Retry-After。
+// 多个分组传入同一个 limiter 时共享配额。
+func RateLimit(limiter *ratelimit.Limiter, key RateLimitKey) func(http.Handler) http.Handler {
+	if key == nil {
+		key = KeyByIP
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			res := limiter.Allow(key(r))
+
+			h := w.Header()
+			h.Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+			h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+			h.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(res.Reset)))
+			if !res.Allowed {
+				h.Set("Retry-After", strconv.Itoa(ceilSeconds(res.RetryAfter)))
+				httpRateLimited.Inc(r.Pattern)
+				problem.Error(w, r, http.StatusTooManyRequests, "rate limit exceeded")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// LimitFailedAuth 按 key 限制认证失败（401）的次数，需放在认证中间件之前。
+// 配额耗尽后直接返回 429，不再执行认证，用于防止暴力尝试凭据；认证成功的请求不消耗配额。
+func LimitFailedAuth(limiter *ratelimit.Limiter, key RateLimitKey) func(http.Handler) http.Handler {
+	if key == nil {
+		key = KeyByIP
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			k := key(r)
+			if res := limiter.Peek(k); !res.Allowed {
+				w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(res.RetryAfter)))
+				httpRateLimited.Inc(r.Pattern)
+				problem.Error(w, r, http.StatusTooManyRequests, "too many failed authentication attempts")
+				return
+			}
+
+			rw := respwriter.Wrap(w)
+			next.ServeHTTP(rw, r)
+			if rw.Status() == http.StatusUnauthorized {
+				limiter.Allow(k)
+			}
+		})
+	}
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,115 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"scaffold/pkg/ratelimit"
+	"testing"
+	"time"
+)
+
+func TestClientIP(t *testing.T) {
+	trusted := []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("fd00::/8"),
+	}
+	tests := []struct {
+		name    string
+		remote  string
+		xff     []string
+		trusted []netip.Prefix
+		want    string
+	}{
+		{"no proxy", "203.0.113.7:1234", nil, trusted, "203.0.113.7"},
+		{"untrusted peer ignores xff", "203.0.113.7:1234", []string{"198.51.100.1"}, trusted, "203.0.113.7"},
+		{"no trusted proxies ignores xff", "10.0.0.1:1234", []string{"198.51.100.1"}, nil, "10.0.0.1"},
+		{"trusted peer", "10.0.0.1:1234", []string{"198.51.100.1"}, trusted, "198.51.100.1"},
+		{"spoofed leftmost hop", "10.0.0.1:1234", []string{"1.2.3.4, 198.51.100.1"}, trusted, "198.51.100.1"},
+		{"chain of trusted proxies", "10.0.0.1:1234", []string{"1.2.3.4, 198.51.100.1, 10.0.0.2"}, trusted, "198.51.100.1"},
+		{"multiple headers", "10.0.0.1:1234", []string{"1.2.3.4", "198.51.100.1, 10.0.0.2"}, trusted, "198.51.100.1"},
+		{"all hops trusted", "10.0.0.1:1234", []string{"10.0.0.3, 10.0.0.2"}, trusted, "10.0.0.3"},
+		{"garbage hop", "10.0.0.1:1234", []string{"198.51.100.1, not-an-ip"}, trusted, "10.0.0.1"},
+		{"ipv4 mapped peer", "[::ffff:10.0.0.1]:1234", []string{"198.51.100.1"}, trusted, "198.51.100.1"},
+		{"ipv6", "[fd00::1]:1234", []string{"2001:db8::1"}, trusted, "2001:db8::1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tt.remote
+			for _, v := range tt.xff {
+				r.Header.Add("X-Forwarded-For", v)
+			}
+			if got := ClientIP(r, tt.trusted).String(); got != tt.want {
+				t.Fatalf("ClientIP = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIPKey(t *testing.T) {
+	tests := []struct {
+		remote string
+		want   string
+	}{
+		{"203.0.113.7:1234", "ip:203.0.113.7"},
+		{"[2001:db8:1:2:aaaa::1]:1234", "ip:2001:db8:1:2::/64"},
+		{"[2001:db8:1:2:bbbb::9]:1234", "ip:2001:db8:1:2::/64"},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.RemoteAddr = tt.remote
+		r.Header.Set("X-Forwarded-For", "198.51.100.1")
+		if got := KeyByIP(r); got != tt.want {
+			t.Errorf("KeyByIP(%s) = %s, want %s", tt.remote, got, tt.want)
+		}
+	}
+}
+
+func TestRateLimitIgnoresSpoofedXFF(t *testing.T) {
+	h := RateLimit(ratelimit.New(1, time.Hour, 1), nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	for i, xff := range []string{"1.1.1.1", "2.2.2.2"} {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("X-Forwarded-For", xff)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, r)
+		want := http.StatusOK
+		if i > 0 {
+			want = http.StatusTooManyRequests
+		}
+		if rec.Code != want {
+			t.Fatalf("request %d: status = %d, want %d", i, rec.Code, want)
+		}
+	}
+}
+
+func TestLimitFailedAuth(t *testing.T) {
+	status := http.StatusUnauthorized
+	calls := 0
+	h := LimitFailedAuth(ratelimit.New(2, time.Hour, 2), nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(status)
+	}))
+	serve := func() int {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		return rec.Code
+	}
+
+	for i, want := range []int{401, 401, 429, 429} {
+		if got := serve(); got != want {
+			t.Fatalf("request %d: status = %d, want %d", i, got, want)
+		}
+	}
+	if calls != 2 {
+		t.Fatalf("handler called %d times after quota exhausted", calls)
+	}
+
+	// 认证成功的请求不消耗配额
+	h = LimitFailedAuth(ratelimit.New(1, time.Hour, 1), nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	for i := range 5 {
+		if got := serve(); got != http.StatusOK {
+			t.Fatalf("request %d: status = %d", i, got)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"scaffold/pkg/common/problem"
+	"scaffold/pkg/common/respwriter"
+	"scaffold/pkg/logger"
+	"scaffold/pkg/metrics"
+)
+
+var httpPanics = metrics.NewCounter("http_panics_total",
+	"Total number of panics recovered in HTTP handlers.")
+
+// PanicError 在其他 goroutine 中捕获的 panic，携带发生 panic 时的堆栈。
+// 将处理器放到单独 goroutine 执行的代码（如超时处理）应以它重新 panic，使日志记录原始堆栈。
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprint(e.Value)
+}
+
+// RecoverMiddleware 捕获处理器 panic，以 error 级别记录堆栈并返回带请求 ID 的 500。
+// 响应已开始写出（如流式响应）时无法再返回错误，改为中断连接。
+// 需放在 LoggingMiddleware、MetricsMiddleware 之内，使它们能记录到 500。
+func RecoverMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rw := respwriter.Wrap(w)
+		defer func() {
+			p := recover()
+			if p == nil {
+				return
+			}
+			if p == http.ErrAbortHandler {
+				// 处理器主动中断，保持 net/http 的语义
+				panic(p)
+			}
+
+			stack := debug.Stack()
+			if pe, ok := p.(*PanicError); ok {
+				p, stack = pe.Value, pe.Stack
+			}
+			httpPanics.Inc()
+			logger.FromContext(r.Context()).With("prefix", "HTTP").Error("panic recovered",
+				"panic", fmt.Sprint(p),
+				"stack", string(stack))
+
+			if rw.WroteHeader() {
+				panic(http.ErrAbortHandler)
+			}
+			problem.Error(rw, r, http.StatusInternalServerError, "")
+		}()
+		next.ServeHTTP(rw, r)
+	})
+}
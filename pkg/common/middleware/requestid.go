@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"net/http"
+	"scaffold/pkg/common/requestid"
+)
+
+// RequestIDMiddleware 沿用调用方传入的合法 X-Request-ID，否则生成新的 ID，
+// 写入请求上下文并在响应头中返回。使用该上下文记录的日志会自动带上 request_id。
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestid.Header)
+		if !requestid.Valid(id) {
+			id = requestid.New()
+		}
+		w.Header().Set(requestid.Header, id)
+		next.ServeHTTP(w, r.WithContext(requestid.NewContext(r.Context(), id)))
+	})
+}
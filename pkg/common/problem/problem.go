@@ -0,0 +1,148 @@
+// Package problem 实现 RFC 9457 problem+json 统一错误响应
+package problem
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"scaffold/pkg/common/requestid"
+)
+
+// ContentType problem+json 的媒体类型
+const ContentType = "application/problem+json"
+
+// FieldError 单个字段的校验错误
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (fe FieldError) Error() string {
+	return fe.Field + ": " + fe.Message
+}
+
+// Problem 统一错误响应体，同时实现 error 接口，可直接作为处理器错误返回
+type Problem struct {
+	Type      string       `json:"type,omitempty"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	RequestID string       `json:"request_id,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+
+	err error
+}
+
+func (p *Problem) Error() string {
+	if p.Detail != "" {
+		return p.Detail
+	}
+	return p.Title
+}
+
+func (p *Problem) Unwrap() error {
+	return p.err
+}
+
+// New 创建指定状态码的错误
+func New(status int, detail string) *Problem {
+	return &Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	}
+}
+
+// Wrap 创建指定状态码的错误并保留原始错误
+func Wrap(status int, err error) *Problem {
+	p := New(status, err.Error())
+	p.err = err
+	return p
+}
+
+// BadRequest 400
+func BadRequest(detail string) *Problem { return New(http.StatusBadRequest, detail) }
+
+// Unauthorized 401
+func Unauthorized(detail string) *Problem { return New(http.StatusUnauthorized, detail) }
+
+// Forbidden 403
+func Forbidden(detail string) *Problem { return New(http.StatusForbidden, detail) }
+
+// NotFound 404
+func NotFound(detail string) *Problem { return New(http.StatusNotFound, detail) }
+
+// Conflict 409
+func Conflict(detail string) *Problem { return New(http.StatusConflict, detail) }
+
+// Validation 422，附带字段错误
+func Validation(errs ...FieldError) *Problem {
+	p := New(http.StatusUnprocessableEntity, "request validation failed")
+	p.Errors = errs
+	return p
+}
+
+// From 将任意错误转换为 Problem，未知错误统一为 500 且不暴露内部信息
+func From(err error) *Problem {
+	var p *Problem
+	if errors.As(err, &p) {
+		cp := *p
+		return &cp
+	}
+
+	var mbe *http.MaxBytesError
+	switch {
+	case errors.As(err, &mbe):
+		return New(http.StatusRequestEntityTooLarge, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return New(http.StatusGatewayTimeout, "request timed out")
+	case errors.Is(err, context.Canceled):
+		// 客户端已断开，状态码仅用于日志
+		p := New(499, "")
+		p.Title = "Client Closed Request"
+		return p
+	}
+	return New(http.StatusInternalServerError, "")
+}
+
+// Write 以 problem+json 格式写出错误
+func Write(w http.ResponseWriter, r *http.Request, p *Problem) {
+	if p.Instance == "" && r != nil {
+		p.Instance = r.URL.Path
+	}
+	if p.RequestID == "" {
+		p.RequestID = requestID(w, r)
+	}
+	if p.Title == "" {
+		p.Title = http.StatusText(p.Status)
+	}
+
+	w.Header().Set("Content-Type", ContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(p.Status)
+	json.NewEncoder(w).Encode(p)
+}
+
+// Error 以 problem+json 格式写出指定状态码的错误
+func Error(w http.ResponseWriter, r *http.Request, status int, detail string) {
+	Write(w, r, New(status, detail))
+}
+
+// requestID 获取请求 ID，优先使用请求上下文中的值
+func requestID(w http.ResponseWriter, r *http.Request) string {
+	if r != nil {
+		if id := requestid.FromContext(r.Context()); id != "" {
+			return id
+		}
+	}
+	if id := w.Header().Get(requestid.Header); id != "" {
+		return id
+	}
+	if r != nil {
+		return r.Header.Get(requestid.Header)
+	}
+	return ""
+}
@@ -0,0 +1,48 @@
+// Package requestid 在请求上下文中传递请求 ID，用于关联同一请求的日志以及与调用方的日志
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+)
+
+// Header 请求 ID 使用的请求/响应头
+const Header = "X-Request-ID"
+
+// maxLen 接受的调用方请求 ID 最大长度
+const maxLen = 128
+
+type ctxKey struct{}
+
+// NewContext 返回携带请求 ID 的上下文
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// FromContext 获取上下文中的请求 ID，不存在时返回空字符串
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(ctxKey{}).(string)
+	return id
+}
+
+// New 生成随机请求 ID
+func New() string {
+	return rand.Text()
+}
+
+// Valid 调用方传入的请求 ID 只允许可打印的常见字符，避免日志注入
+func Valid(id string) bool {
+	if id == "" || len(id) > maxLen {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		c := id[i]
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':', c == '/', c == '+', c == '=', c == '@':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,211 @@
+// Package respwriter 提供记录状态码、写出字节数和首字节时间的 http.ResponseWriter 包装，
+// 包装后只暴露底层 ResponseWriter 实际支持的可选接口（http.Flusher、http.Hijacker、io.ReaderFrom），
+// 避免中间件遮蔽流式响应、sendfile 和协议升级能力。
+package respwriter
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Writer 包装后的 ResponseWriter
+type Writer interface {
+	http.ResponseWriter
+	// Unwrap 供 http.ResponseController 访问底层 ResponseWriter
+	Unwrap() http.ResponseWriter
+	// Status 响应状态码，处理器未写出任何内容时为 200，连接被接管时为 101
+	Status() int
+	// Written 已写出的响应体字节数
+	Written() int64
+	// WroteHeader 是否已写出响应头
+	WroteHeader() bool
+	// TimeToFirstByte 从包装到写出响应头的时间，尚未写出时为 0
+	TimeToFirstByte() time.Duration
+	// OnWrite 注册写出响应体时的回调，回调在 Write 之前执行，不能保留 p。
+	// 通过 io.ReaderFrom 写出的内容不经过回调。
+	OnWrite(fn func(status int, p []byte))
+}
+
+// Wrap 包装 w，返回值实现的可选接口与 w 一致
+func Wrap(w http.ResponseWriter) Writer {
+	rw := &recorder{ResponseWriter: w, start: time.Now()}
+
+	_, isFlusher := w.(http.Flusher)
+	_, isHijacker := w.(http.Hijacker)
+	_, isReaderFrom := w.(io.ReaderFrom)
+
+	switch {
+	case isFlusher && isHijacker && isReaderFrom:
+		return struct {
+			*recorder
+			flusher
+			hijacker
+			readerFrom
+		}{rw, flusher{rw}, hijacker{rw}, readerFrom{rw}}
+	case isFlusher && isHijacker:
+		return struct {
+			*recorder
+			flusher
+			hijacker
+		}{rw, flusher{rw}, hijacker{rw}}
+	case isFlusher && isReaderFrom:
+		return struct {
+			*recorder
+			flusher
+			readerFrom
+		}{rw, flusher{rw}, readerFrom{rw}}
+	case isHijacker && isReaderFrom:
+		return struct {
+			*recorder
+			hijacker
+			readerFrom
+		}{rw, hijacker{rw}, readerFrom{rw}}
+	case isFlusher:
+		return struct {
+			*recorder
+			flusher
+		}{rw, flusher{rw}}
+	case isHijacker:
+		return struct {
+			*recorder
+			hijacker
+		}{rw, hijacker{rw}}
+	case isReaderFrom:
+		return struct {
+			*recorder
+			readerFrom
+		}{rw, readerFrom{rw}}
+	default:
+		return rw
+	}
+}
+
+type recorder struct {
+	http.ResponseWriter
+	start time.Time
+
+	mu          sync.Mutex
+	status      int
+	wroteHeader bool
+	written     int64
+	ttfb        time.Duration
+	hooks       []func(int, []byte)
+}
+
+func (rw *recorder) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+func (rw *recorder) WriteHeader(code int) {
+	rw.mu.Lock()
+	// 1xx 信息响应（101 除外）之后还会有最终响应
+	if !rw.wroteHeader && (code >= 200 || code == http.StatusSwitchingProtocols) {
+		rw.markHeaderLocked(code)
+	}
+	rw.mu.Unlock()
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+func (rw *recorder) Write(p []byte) (int, error) {
+	rw.mu.Lock()
+	if !rw.wroteHeader {
+		rw.markHeaderLocked(http.StatusOK)
+	}
+	status, hooks := rw.status, rw.hooks
+	rw.mu.Unlock()
+
+	for _, fn := range hooks {
+		fn(status, p)
+	}
+	n, err := rw.ResponseWriter.Write(p)
+	rw.addWritten(int64(n))
+	return n, err
+}
+
+func (rw *recorder) markHeaderLocked(code int) {
+	rw.wroteHeader = true
+	rw.status = code
+	rw.ttfb = time.Since(rw.start)
+}
+
+func (rw *recorder) addWritten(n int64) {
+	rw.mu.Lock()
+	rw.written += n
+	rw.mu.Unlock()
+}
+
+func (rw *recorder) Status() int {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	if !rw.wroteHeader {
+		return http.StatusOK
+	}
+	return rw.status
+}
+
+func (rw *recorder) Written() int64 {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	return rw.written
+}
+
+func (rw *recorder) WroteHeader() bool {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	return rw.wroteHeader
+}
+
+func (rw *recorder) TimeToFirstByte() time.Duration {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	return rw.ttfb
+}
+
+func (rw *recorder) OnWrite(fn func(status int, p []byte)) {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	rw.hooks = append(rw.hooks, fn)
+}
+
+type flusher struct{ rw *recorder }
+
+// Flush 刷新时若尚未写出响应头，底层会以 200 写出
+func (f flusher) Flush() {
+	f.rw.mu.Lock()
+	if !f.rw.wroteHeader {
+		f.rw.markHeaderLocked(http.StatusOK)
+	}
+	f.rw.mu.Unlock()
+	f.rw.ResponseWriter.(http.Flusher).Flush()
+}
+
+type hijacker struct{ rw *recorder }
+
+func (h hijacker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, brw, err := h.rw.ResponseWriter.(http.Hijacker).Hijack()
+	if err == nil {
+		h.rw.mu.Lock()
+		if !h.rw.wroteHeader {
+			h.rw.markHeaderLocked(http.StatusSwitchingProtocols)
+		}
+		h.rw.mu.Unlock()
+	}
+	return conn, brw, err
+}
+
+type readerFrom struct{ rw *recorder }
+
+func (r readerFrom) ReadFrom(src io.Reader) (int64, error) {
+	r.rw.mu.Lock()
+	if !r.rw.wroteHeader {
+		r.rw.markHeaderLocked(http.StatusOK)
+	}
+	r.rw.mu.Unlock()
+	n, err := r.rw.ResponseWriter.(io.ReaderFrom).ReadFrom(src)
+	r.rw.addWritten(n)
+	return n, err
+}
@@ -0,0 +1,236 @@
+package respwriter
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// base 只实现 http.ResponseWriter 的底层写入器，可选接口由下面的类型组合
+type base struct {
+	header   http.Header
+	codes    []int
+	body     bytes.Buffer
+	flushed  bool
+	hijacked bool
+}
+
+func (b *base) Header() http.Header { return b.header }
+
+func (b *base) Write(p []byte) (int, error) { return b.body.Write(p) }
+
+func (b *base) WriteHeader(code int) { b.codes = append(b.codes, code) }
+
+type baseFlusher struct{ b *base }
+
+func (f baseFlusher) Flush() { f.b.flushed = true }
+
+type baseHijacker struct{ b *base }
+
+func (h baseHijacker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h.b.hijacked = true
+	return nil, nil, nil
+}
+
+type baseReaderFrom struct{ b *base }
+
+func (r baseReaderFrom) ReadFrom(src io.Reader) (int64, error) { return r.b.body.ReadFrom(src) }
+
+// newBase 按组合创建底层写入器
+func newBase(flusher, hijacker, readerFrom bool) (*base, http.ResponseWriter) {
+	b := &base{header: http.Header{}}
+	f, h, r := baseFlusher{b}, baseHijacker{b}, baseReaderFrom{b}
+	switch {
+	case flusher && hijacker && readerFrom:
+		return b, struct {
+			*base
+			baseFlusher
+			baseHijacker
+			baseReaderFrom
+		}{b, f, h, r}
+	case flusher && hijacker:
+		return b, struct {
+			*base
+			baseFlusher
+			baseHijacker
+		}{b, f, h}
+	case flusher && readerFrom:
+		return b, struct {
+			*base
+			baseFlusher
+			baseReaderFrom
+		}{b, f, r}
+	case hijacker && readerFrom:
+		return b, struct {
+			*base
+			baseHijacker
+			baseReaderFrom
+		}{b, h, r}
+	case flusher:
+		return b, struct {
+			*base
+			baseFlusher
+		}{b, f}
+	case hijacker:
+		return b, struct {
+			*base
+			baseHijacker
+		}{b, h}
+	case readerFrom:
+		return b, struct {
+			*base
+			baseReaderFrom
+		}{b, r}
+	default:
+		return b, b
+	}
+}
+
+func TestWrapInterfaces(t *testing.T) {
+	for i := range 8 {
+		flusher, hijacker, readerFrom := i&1 != 0, i&2 != 0, i&4 != 0
+		name := fmt.Sprintf("flusher=%v,hijacker=%v,readerfrom=%v", flusher, hijacker, readerFrom)
+		t.Run(name, func(t *testing.T) {
+			b, w := newBase(flusher, hijacker, readerFrom)
+			rw := Wrap(w)
+
+			if _, ok := rw.(http.Flusher); ok != flusher {
+				t.Fatalf("http.Flusher = %v, want %v", ok, flusher)
+			}
+			if _, ok := rw.(http.Hijacker); ok != hijacker {
+				t.Fatalf("http.Hijacker = %v, want %v", ok, hijacker)
+			}
+			if _, ok := rw.(io.ReaderFrom); ok != readerFrom {
+				t.Fatalf("io.ReaderFrom = %v, want %v", ok, readerFrom)
+			}
+			if rw.Unwrap() != w {
+				t.Fatal("Unwrap does not return the underlying writer")
+			}
+
+			// ResponseController 经 Unwrap 找到底层实现，不支持时返回错误
+			rc := http.NewResponseController(rw)
+			if err := rc.Flush(); (err == nil) != flusher || b.flushed != flusher {
+				t.Fatalf("Flush err = %v, flushed = %v", err, b.flushed)
+			}
+			if _, _, err := rc.Hijack(); (err == nil) != hijacker || b.hijacked != hijacker {
+				t.Fatalf("Hijack err = %v, hijacked = %v", err, b.hijacked)
+			}
+			if err := rc.SetWriteDeadline(time.Now()); err == nil {
+				t.Fatal("SetWriteDeadline should not be supported")
+			}
+		})
+	}
+}
+
+func TestWrapRecords(t *testing.T) {
+	tests := []struct {
+		name    string
+		serve   func(w http.ResponseWriter)
+		status  int
+		wrote   bool
+		written int64
+		codes   []int
+	}{
+		{name: "nothing written", status: http.StatusOK,
+			serve: func(w http.ResponseWriter) {}},
+		{name: "implicit 200", status: http.StatusOK, wrote: true, written: 5,
+			serve: func(w http.ResponseWriter) { io.WriteString(w, "hello") }},
+		{name: "explicit status", status: http.StatusNotFound, wrote: true, written: 3, codes: []int{404},
+			serve: func(w http.ResponseWriter) {
+				w.WriteHeader(http.StatusNotFound)
+				io.WriteString(w, "abc")
+			}},
+		{name: "second WriteHeader ignored", status: http.StatusCreated, wrote: true, codes: []int{201, 500},
+			serve: func(w http.ResponseWriter) {
+				w.WriteHeader(http.StatusCreated)
+				w.WriteHeader(http.StatusInternalServerError)
+			}},
+		{name: "1xx before final status", status: http.StatusOK, wrote: true, written: 2, codes: []int{103, 200},
+			serve: func(w http.ResponseWriter) {
+				w.WriteHeader(http.StatusEarlyHints)
+				w.WriteHeader(http.StatusOK)
+				io.WriteString(w, "ok")
+			}},
+		{name: "only 1xx", status: http.StatusOK, codes: []int{103},
+			serve: func(w http.ResponseWriter) { w.WriteHeader(http.StatusEarlyHints) }},
+		{name: "101 is final", status: http.StatusSwitchingProtocols, wrote: true, codes: []int{101},
+			serve: func(w http.ResponseWriter) { w.WriteHeader(http.StatusSwitchingProtocols) }},
+		{name: "hijack", status: http.StatusSwitchingProtocols, wrote: true,
+			serve: func(w http.ResponseWriter) { w.(http.Hijacker).Hijack() }},
+		{name: "flush", status: http.StatusOK, wrote: true,
+			serve: func(w http.ResponseWriter) { w.(http.Flusher).Flush() }},
+		{name: "read from", status: http.StatusOK, wrote: true, written: 6,
+			serve: func(w http.ResponseWriter) { w.(io.ReaderFrom).ReadFrom(strings.NewReader("sendfi")) }},
+		{name: "write then read from", status: http.StatusAccepted, wrote: true, written: 4, codes: []int{202},
+			serve: func(w http.ResponseWriter) {
+				w.WriteHeader(http.StatusAccepted)
+				io.WriteString(w, "ab")
+				w.(io.ReaderFrom).ReadFrom(strings.NewReader("cd"))
+			}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, w := newBase(true, true, true)
+			rw := Wrap(w)
+			time.Sleep(time.Millisecond)
+			tt.serve(rw)
+
+			if got := rw.Status(); got != tt.status {
+				t.Fatalf("Status = %d, want %d", got, tt.status)
+			}
+			if got := rw.WroteHeader(); got != tt.wrote {
+				t.Fatalf("WroteHeader = %v, want %v", got, tt.wrote)
+			}
+			if got := rw.Written(); got != tt.written {
+				t.Fatalf("Written = %d, want %d", got, tt.written)
+			}
+			if got := int64(b.body.Len()); got != tt.written {
+				t.Fatalf("underlying body = %d bytes, want %d", got, tt.written)
+			}
+			ttfb := rw.TimeToFirstByte()
+			if tt.wrote && ttfb < time.Millisecond {
+				t.Fatalf("TimeToFirstByte = %v, want at least 1ms", ttfb)
+			}
+			if !tt.wrote && ttfb != 0 {
+				t.Fatalf("TimeToFirstByte = %v before header was written", ttfb)
+			}
+			if len(b.codes) != len(tt.codes) {
+				t.Fatalf("underlying WriteHeader calls = %v, want %v", b.codes, tt.codes)
+			}
+			for i := range b.codes {
+				if b.codes[i] != tt.codes[i] {
+					t.Fatalf("underlying WriteHeader calls = %v, want %v", b.codes, tt.codes)
+				}
+			}
+		})
+	}
+}
+
+func TestOnWrite(t *testing.T) {
+	_, w := newBase(false, false, true)
+	rw := Wrap(w)
+	var got []string
+	rw.OnWrite(func(status int, p []byte) {
+		got = append(got, string(p)+"@"+http.StatusText(status))
+	})
+
+	rw.WriteHeader(http.StatusTeapot)
+	io.WriteString(rw, "a")
+	io.WriteString(rw, "b")
+	// 通过 io.ReaderFrom 写出的内容不经过回调
+	rw.(io.ReaderFrom).ReadFrom(strings.NewReader("c"))
+
+	want := "a@I'm a teapot,b@I'm a teapot"
+	if strings.Join(got, ",") != want {
+		t.Fatalf("hooks = %q, want %q", got, want)
+	}
+	if rw.Written() != 3 {
+		t.Fatalf("Written = %d", rw.Written())
+	}
+}
@@ -1,13 +1,28 @@
 package eventbus
 
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
 type EventBus struct {
 	data chan any
+
+	seq  atomic.Uint64
+	mu   sync.RWMutex
+	subs map[*Subscription]struct{}
 }
 
+// Default 进程内默认事件总线
+var Default = New(1024)
+
 // New 创建一个事件总线实例
 func New(bufferSize int) *EventBus {
 	return &EventBus{
 		data: make(chan any, bufferSize),
+		subs: make(map[*Subscription]struct{}),
 	}
 }
 
@@ -20,3 +35,183 @@ func (e *EventBus) Publish(value any) {
 func (e *EventBus) Subscribe() <-chan any {
 	return e.data
 }
+
+// Event 带主题的事件，ID 在同一总线内单调递增
+type Event struct {
+	ID    uint64    `json:"id"`
+	Topic string    `json:"topic"`
+	Data  any       `json:"data"`
+	Time  time.Time `json:"time"`
+}
+
+// Emit 向订阅了该主题的所有订阅者广播事件，不会阻塞：订阅者缓冲区满时丢弃并计数。
+// 与 Publish/Subscribe 的队列语义相互独立。
+func (e *EventBus) Emit(topic string, data any) Event {
+	// 持写锁分配 ID 并投递，保证每个订阅者收到的事件 ID 有序
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	ev := Event{
+		ID:    e.seq.Add(1),
+		Topic: topic,
+		Data:  data,
+		Time:  time.Now(),
+	}
+	for s := range e.subs {
+		if s.Matches(topic) {
+			s.deliver(ev)
+		}
+	}
+	return ev
+}
+
+// SubscribeTopics 按主题订阅广播事件，buffer 为该订阅者的缓冲大小。
+// 主题支持精确匹配、"*" 匹配全部以及 "device.*" 形式的前缀匹配，不传主题时不接收任何事件。
+func (e *EventBus) SubscribeTopics(buffer int, topics ...string) *Subscription {
+	s := &Subscription{
+		bus:    e,
+		ch:     make(chan Event, buffer),
+		topics: make(map[string]struct{}),
+	}
+	s.Add(topics...)
+
+	e.mu.Lock()
+	e.subs[s] = struct{}{}
+	e.mu.Unlock()
+	return s
+}
+
+// SubscribeFunc 按主题订阅广播事件，fn 在 Emit 中持总线锁同步调用，调用返回时事件已经处理完毕。
+// fn 必须快速返回且不能调用总线的方法，适合记录历史等需要与订阅严格有序的场景。
+func (e *EventBus) SubscribeFunc(fn func(Event), topics ...string) *Subscription {
+	s := &Subscription{
+		bus:    e,
+		fn:     fn,
+		topics: make(map[string]struct{}),
+	}
+	s.Add(topics...)
+
+	e.mu.Lock()
+	e.subs[s] = struct{}{}
+	e.mu.Unlock()
+	return s
+}
+
+// LastID 返回最近一次广播事件的 ID
+func (e *EventBus) LastID() uint64 {
+	return e.seq.Load()
+}
+
+// Subscription 主题订阅
+type Subscription struct {
+	bus *EventBus
+	ch  chan Event
+	fn  func(Event) // SubscribeFunc 创建的订阅没有 channel
+
+	mu      sync.RWMutex
+	topics  map[string]struct{}
+	closed  bool
+	dropped atomic.Uint64
+}
+
+// C 返回事件 channel，取消订阅后关闭；SubscribeFunc 创建的订阅返回 nil
+func (s *Subscription) C() <-chan Event {
+	return s.ch
+}
+
+// Add 增加订阅主题
+func (s *Subscription) Add(topics ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, t := range topics {
+		s.topics[t] = struct{}{}
+	}
+}
+
+// Remove 取消部分主题
+func (s *Subscription) Remove(topics ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, t := range topics {
+		delete(s.topics, t)
+	}
+}
+
+// Topics 返回当前订阅的主题
+func (s *Subscription) Topics() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]string, 0, len(s.topics))
+	for t := range s.topics {
+		out = append(out, t)
+	}
+	return out
+}
+
+// Matches 主题是否在订阅范围内
+func (s *Subscription) Matches(topic string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return matchTopics(s.topics, topic)
+}
+
+// Dropped 因缓冲区满而丢弃的事件数
+func (s *Subscription) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Unsubscribe 取消订阅并关闭 channel，可重复调用
+func (s *Subscription) Unsubscribe() {
+	s.bus.mu.Lock()
+	delete(s.bus.subs, s)
+	s.bus.mu.Unlock()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.closed {
+		s.closed = true
+		if s.ch != nil {
+			close(s.ch)
+		}
+	}
+}
+
+func (s *Subscription) deliver(ev Event) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return
+	}
+	if s.fn != nil {
+		s.fn(ev)
+		return
+	}
+	select {
+	case s.ch <- ev:
+	default:
+		s.dropped.Add(1)
+	}
+}
+
+// MatchTopic 判断主题是否匹配模式，模式支持 "*" 和 "prefix.*"
+func MatchTopic(pattern, topic string) bool {
+	if pattern == "*" || pattern == topic {
+		return true
+	}
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+		return strings.HasPrefix(topic, prefix)
+	}
+	return false
+}
+
+func matchTopics(patterns map[string]struct{}, topic string) bool {
+	if _, ok := patterns[topic]; ok {
+		return true
+	}
+	for p := range patterns {
+		if MatchTopic(p, topic) {
+			return true
+		}
+	}
+	return false
+}
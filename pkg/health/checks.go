@@ -0,0 +1,44 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Writable 检查目录可写：创建并删除一个临时文件
+func Writable(dir string) CheckFunc {
+	return func(ctx context.Context) error {
+		if dir == "" {
+			dir = "."
+		}
+		f, err := os.CreateTemp(dir, ".health-*")
+		if err != nil {
+			return err
+		}
+		name := f.Name()
+		f.Close()
+		return os.Remove(name)
+	}
+}
+
+// DiskFree 检查目录所在磁盘的可用空间不低于 minBytes
+func DiskFree(dir string, minBytes uint64) CheckFunc {
+	return func(ctx context.Context) error {
+		if dir == "" {
+			dir = "."
+		}
+		free, err := diskFree(dir)
+		if errors.Is(err, errors.ErrUnsupported) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if free < minBytes {
+			return fmt.Errorf("disk free %d MB below %d MB", free>>20, minBytes>>20)
+		}
+		return nil
+	}
+}
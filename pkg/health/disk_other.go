@@ -0,0 +1,9 @@
+//go:build !linux && !darwin && !freebsd && !windows
+
+package health
+
+import "errors"
+
+func diskFree(dir string) (uint64, error) {
+	return 0, errors.ErrUnsupported
+}
@@ -0,0 +1,216 @@
+// Package health 提供存活/就绪探针及可插拔的健康检查
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	defaultTimeout  = 2 * time.Second
+	defaultCacheTTL = 5 * time.Second
+)
+
+// CheckFunc 健康检查函数，返回 nil 表示健康
+type CheckFunc func(ctx context.Context) error
+
+// Option 检查项选项
+type Option func(*check)
+
+// WithTimeout 设置单个检查的超时时间
+func WithTimeout(d time.Duration) Option {
+	return func(c *check) { c.timeout = d }
+}
+
+// WithCacheTTL 设置检查结果缓存时间，0 表示不缓存
+func WithCacheTTL(d time.Duration) Option {
+	return func(c *check) { c.ttl = d }
+}
+
+// Result 单个检查的结果
+type Result struct {
+	Status    string    `json:"status"`
+	Error     string    `json:"error,omitempty"`
+	Duration  string    `json:"duration"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// Report 所有检查的汇总
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks,omitempty"`
+	Info   map[string]any    `json:"info,omitempty"`
+}
+
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+type check struct {
+	name    string
+	fn      CheckFunc
+	timeout time.Duration
+	ttl     time.Duration
+
+	mu     sync.Mutex
+	last   Result
+	cached bool
+}
+
+var (
+	mu     sync.RWMutex
+	checks = map[string]*check{}
+	infos  = map[string]func() any{}
+)
+
+// Register 注册健康检查，同名检查会被替换
+func Register(name string, fn CheckFunc, opts ...Option) {
+	c := &check{
+		name:    name,
+		fn:      fn,
+		timeout: defaultTimeout,
+		ttl:     defaultCacheTTL,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	checks[name] = c
+}
+
+// Unregister 移除健康检查
+func Unregister(name string) {
+	mu.Lock()
+	defer mu.Unlock()
+	delete(checks, name)
+}
+
+// RegisterInfo 注册附加在就绪报告中的运行信息（如连接数），不影响健康状态
+func RegisterInfo(name string, fn func() any) {
+	mu.Lock()
+	defer mu.Unlock()
+	infos[name] = fn
+}
+
+// UnregisterInfo 移除运行信息
+func UnregisterInfo(name string) {
+	mu.Lock()
+	defer mu.Unlock()
+	delete(infos, name)
+}
+
+// Check 并发执行所有检查，缓存未过期的检查直接返回上次结果
+func Check(ctx context.Context) Report {
+	mu.RLock()
+	list := make([]*check, 0, len(checks))
+	for _, c := range checks {
+		list = append(list, c)
+	}
+	info := make(map[string]any, len(infos))
+	for name, fn := range infos {
+		info[name] = fn()
+	}
+	mu.RUnlock()
+	sort.Slice(list, func(i, j int) bool { return list[i].name < list[j].name })
+
+	results := make([]Result, len(list))
+	var wg sync.WaitGroup
+	for i, c := range list {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = c.run(ctx)
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOK, Checks: make(map[string]Result, len(list))}
+	if len(info) > 0 {
+		report.Info = info
+	}
+	for i, c := range list {
+		report.Checks[c.name] = results[i]
+		if results[i].Status != StatusOK {
+			report.Status = StatusFail
+		}
+	}
+	return report
+}
+
+func (c *check) run(ctx context.Context) Result {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.cached && time.Since(c.last.CheckedAt) < c.ttl {
+		return c.last
+	}
+
+	// 结果会被缓存并提供给其他探针，不能受发起本次检查的请求取消或超时影响
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := safeRun(ctx, c.fn)
+	res := Result{
+		Status:    StatusOK,
+		Duration:  time.Since(start).String(),
+		CheckedAt: start,
+	}
+	if err != nil {
+		res.Status = StatusFail
+		res.Error = err.Error()
+	}
+
+	c.last, c.cached = res, true
+	return res
+}
+
+// safeRun 执行检查，超时或 panic 均视为失败
+func safeRun(ctx context.Context, fn CheckFunc) error {
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				done <- fmt.Errorf("check panic: %v", p)
+			}
+		}()
+		done <- fn(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// LivenessHandler 存活探针，进程能响应即返回 200
+func LivenessHandler(w http.ResponseWriter, r *http.Request) {
+	writeReport(w, http.StatusOK, Report{Status: StatusOK})
+}
+
+// ReadinessHandler 就绪探针，所有检查通过返回 200，否则返回 503
+func ReadinessHandler(w http.ResponseWriter, r *http.Request) {
+	report := Check(r.Context())
+	status := http.StatusOK
+	if report.Status != StatusOK {
+		status = http.StatusServiceUnavailable
+	}
+	writeReport(w, status, report)
+}
+
+func writeReport(w http.ResponseWriter, status int, report Report) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,61 @@
+package health
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCheckIgnoresCallerCancel(t *testing.T) {
+	Register("slow", func(ctx context.Context) error {
+		select {
+		case <-time.After(20 * time.Millisecond):
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+	defer Unregister("slow")
+
+	// 探针请求在检查完成前断开
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	Check(ctx)
+
+	if r := Check(context.Background()); r.Status != StatusOK {
+		t.Fatalf("status = %s, checks = %+v", r.Status, r.Checks)
+	}
+}
+
+func TestCheckTimeoutAndCache(t *testing.T) {
+	var calls atomic.Int32
+	Register("hang", func(ctx context.Context) error {
+		calls.Add(1)
+		<-ctx.Done()
+		return ctx.Err()
+	}, WithTimeout(10*time.Millisecond))
+	defer Unregister("hang")
+
+	r := Check(context.Background())
+	if r.Status != StatusFail {
+		t.Fatalf("status = %s", r.Status)
+	}
+	if got := r.Checks["hang"].Error; got != context.DeadlineExceeded.Error() {
+		t.Fatalf("error = %q", got)
+	}
+	Check(context.Background())
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("calls = %d, want cached result", n)
+	}
+}
+
+func TestCheckPanic(t *testing.T) {
+	Register("panic", func(ctx context.Context) error { panic("boom") })
+	defer Unregister("panic")
+
+	r := Check(context.Background())
+	if r.Status != StatusFail || r.Checks["panic"].Error != "check panic: boom" {
+		t.Fatalf("report = %+v", r)
+	}
+}
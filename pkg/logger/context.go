@@ -0,0 +1,106 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"scaffold/pkg/common/requestid"
+	"time"
+)
+
+type (
+	ctxLoggerKey struct{}
+	ctxAttrsKey  struct{}
+)
+
+// NewContext 返回携带 l 的上下文，之后可通过 FromContext 取回
+func NewContext(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, ctxLoggerKey{}, l)
+}
+
+// FromContext 返回上下文中的 logger（没有时为默认 logger），
+// 其输出的每条日志都带有上下文中的请求 ID 和 AppendContext 添加的属性
+func FromContext(ctx context.Context) *slog.Logger {
+	l, ok := ctx.Value(ctxLoggerKey{}).(*slog.Logger)
+	if !ok {
+		l = slog.Default()
+	}
+
+	var h *ContextHandler
+	if ch, ok := l.Handler().(*ContextHandler); ok {
+		h = &ContextHandler{handler: ch.handler}
+	} else {
+		h = NewContextHandler(l.Handler())
+	}
+	h.bound = ctx
+	return slog.New(h)
+}
+
+// AppendContext 在上下文中追加日志属性，参数形式同 slog.Logger.Info 的 args
+func AppendContext(ctx context.Context, args ...any) context.Context {
+	r := slog.NewRecord(time.Time{}, 0, "", 0)
+	r.Add(args...)
+
+	prev, _ := ctx.Value(ctxAttrsKey{}).([]slog.Attr)
+	attrs := make([]slog.Attr, 0, len(prev)+r.NumAttrs())
+	attrs = append(attrs, prev...)
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a)
+		return true
+	})
+	return context.WithValue(ctx, ctxAttrsKey{}, attrs)
+}
+
+// contextAttrs 上下文中需要附加到日志的属性
+func contextAttrs(ctx context.Context) []slog.Attr {
+	if ctx == nil {
+		return nil
+	}
+	attrs, _ := ctx.Value(ctxAttrsKey{}).([]slog.Attr)
+	if id := requestid.FromContext(ctx); id != "" {
+		attrs = append([]slog.Attr{slog.String("request_id", id)}, attrs...)
+	}
+	return attrs
+}
+
+// ContextHandler 包装 slog.Handler，将上下文中的请求 ID 和属性添加到每条日志，
+// 配合 slog.InfoContext 等带上下文的方法使用
+type ContextHandler struct {
+	handler slog.Handler
+	bound   context.Context // FromContext 绑定的上下文，优先于调用时传入的上下文
+}
+
+// NewContextHandler 创建上下文处理器
+func NewContextHandler(h slog.Handler) *ContextHandler {
+	return &ContextHandler{handler: h}
+}
+
+func (h *ContextHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+func (h *ContextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if h.bound != nil {
+		ctx = h.bound
+	}
+	attrs := contextAttrs(ctx)
+	if len(attrs) == 0 {
+		return h.handler.Handle(ctx, r)
+	}
+
+	// 上下文属性排在记录属性之前，便于按请求查看日志
+	nr := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	nr.AddAttrs(attrs...)
+	r.Attrs(func(a slog.Attr) bool {
+		nr.AddAttrs(a)
+		return true
+	})
+	return h.handler.Handle(ctx, nr)
+}
+
+func (h *ContextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &ContextHandler{handler: h.handler.WithAttrs(attrs), bound: h.bound}
+}
+
+func (h *ContextHandler) WithGroup(name string) slog.Handler {
+	return &ContextHandler{handler: h.handler.WithGroup(name), bound: h.bound}
+}
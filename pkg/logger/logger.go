@@ -2,11 +2,13 @@ package logger
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -24,6 +26,9 @@ type RotateFileWriter struct {
 	maxAge     int // 单位：天
 	size       int64
 	file       *os.File
+
+	mu  sync.Mutex
+	err error // 最近一次写入或轮转的错误
 }
 
 // NewRotateFileWriter 创建一个新的日志轮转写入器
@@ -64,20 +69,34 @@ func NewRotateFileWriter(filename string, maxSize int, maxBackups, maxAge int) (
 
 // Write 实现 io.Writer 接口
 func (w *RotateFileWriter) Write(p []byte) (n int, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
 	// 检查是否需要轮转
 	if w.size+int64(len(p)) >= w.maxSize {
 		if err := w.rotate(); err != nil {
+			w.err = err
 			return 0, err
 		}
 	}
 
 	n, err = w.file.Write(p)
 	w.size += int64(n)
+	w.err = err
 	return n, err
 }
 
+// Err 返回最近一次写入的错误，nil 表示写入正常
+func (w *RotateFileWriter) Err() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
+
 // Close 关闭文件
 func (w *RotateFileWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
 	return w.file.Close()
 }
 
@@ -171,26 +190,37 @@ func (w *RotateFileWriter) cleanOldLogs() {
 // 自定义格式化处理器
 type customHandler struct {
 	w          io.Writer
-	level      slog.Level
+	level      slog.Leveler
 	withSource bool
+
+	attrs []slog.Attr // 通过 With 添加的属性，键已带分组前缀
+	group string      // 当前分组前缀，如 "a.b."
 }
 
 func (h *customHandler) Enabled(_ context.Context, level slog.Level) bool {
-	return level >= h.level
+	return level >= h.level.Level()
 }
 
 func (h *customHandler) Handle(_ context.Context, r slog.Record) error {
 	timestamp := r.Time.Format("2006-01-02T15:04:05.000")
 	levelStr := r.Level.String()
 
+	// 合并 With 添加的属性和本条记录的属性
+	attrs := make([]slog.Attr, 0, len(h.attrs)+r.NumAttrs())
+	attrs = append(attrs, h.attrs...)
+	r.Attrs(func(a slog.Attr) bool {
+		a.Key = h.group + a.Key
+		attrs = append(attrs, a)
+		return true
+	})
+
 	var prefix string
 	// 尝试获取前缀
-	r.Attrs(func(a slog.Attr) bool {
+	for _, a := range attrs {
 		if a.Key == "prefix" {
 			prefix = a.Value.String()
 		}
-		return true
-	})
+	}
 
 	var builder strings.Builder
 	builder.WriteString("[")
@@ -209,7 +239,7 @@ func (h *customHandler) Handle(_ context.Context, r slog.Record) error {
 	builder.WriteString(r.Message)
 
 	// 添加其他属性（排除已处理的前缀）
-	r.Attrs(func(a slog.Attr) bool {
+	for _, a := range attrs {
 		if a.Key != "prefix" {
 			builder.WriteString(" ")
 			builder.WriteString(a.Key)
@@ -225,8 +255,7 @@ func (h *customHandler) Handle(_ context.Context, r slog.Record) error {
 				builder.WriteString(val)
 			}
 		}
-		return true
-	})
+	}
 
 	builder.WriteString("\r\n")
 
@@ -235,20 +264,50 @@ func (h *customHandler) Handle(_ context.Context, r slog.Record) error {
 }
 
 func (h *customHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
-	// 创建一个新的处理器，属性会在日志记录时处理
-	return h
+	if len(attrs) == 0 {
+		return h
+	}
+	h2 := *h
+	h2.attrs = make([]slog.Attr, 0, len(h.attrs)+len(attrs))
+	h2.attrs = append(h2.attrs, h.attrs...)
+	for _, a := range attrs {
+		a.Key = h.group + a.Key
+		h2.attrs = append(h2.attrs, a)
+	}
+	return &h2
 }
 
 func (h *customHandler) WithGroup(name string) slog.Handler {
-	// 简单实现，忽略分组
-	return h
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.group = h.group + name + "."
+	return &h2
+}
+
+// logLevel 全局日志级别，所有处理器共享，可在运行时调整
+var logLevel = func() *slog.LevelVar {
+	v := new(slog.LevelVar)
+	v.Set(slog.LevelDebug)
+	return v
+}()
+
+// Level 返回当前日志级别
+func Level() slog.Level {
+	return logLevel.Level()
+}
+
+// SetLevel 运行时调整日志级别
+func SetLevel(level slog.Level) {
+	logLevel.Set(level)
 }
 
 // 创建自定义处理器
 func newTextHandler(w io.Writer) slog.Handler {
 	return &customHandler{
 		w:          w,
-		level:      slog.LevelDebug,
+		level:      logLevel,
 		withSource: false,
 	}
 }
@@ -290,6 +349,32 @@ func (h *MyHandler) Enabled(ctx context.Context, level slog.Level) bool {
 	return h.appHandler.Enabled(ctx, level)
 }
 
+// fileWriters 当前使用的日志文件写入器，为空表示未启用文件日志
+var (
+	fileWritersMu sync.RWMutex
+	fileWriters   []*RotateFileWriter
+)
+
+// FileLogging 是否启用了文件日志，未启用时日志输出到标准错误
+func FileLogging() bool {
+	fileWritersMu.RLock()
+	defer fileWritersMu.RUnlock()
+	return len(fileWriters) > 0
+}
+
+// Healthy 检查文件日志是否正常写入。回退到标准错误输出是预期行为，不视为异常。
+func Healthy() error {
+	fileWritersMu.RLock()
+	defer fileWritersMu.RUnlock()
+
+	for _, w := range fileWriters {
+		if err := w.Err(); err != nil {
+			return fmt.Errorf("%s: %w", w.filename, err)
+		}
+	}
+	return nil
+}
+
 // InitMyLog 初始化日志系统
 func InitMyLog() error {
 	// 获取可执行文件所在目录
@@ -322,6 +407,7 @@ func InitMyLog() error {
 		512,  // 保留512天
 	)
 	if err != nil {
+		appLogWriter.Close()
 		return err
 	}
 
@@ -334,12 +420,21 @@ func InitMyLog() error {
 		errorHandler: newTextHandler(errorLogWriter),
 	}
 
-	// 设置全局日志处理器
-	slog.SetDefault(slog.New(handler))
+	// 设置全局日志处理器，外层附加上下文中的请求属性
+	slog.SetDefault(slog.New(NewContextHandler(handler)))
+
+	fileWritersMu.Lock()
+	fileWriters = []*RotateFileWriter{appLogWriter, errorLogWriter}
+	fileWritersMu.Unlock()
 
 	return nil
 }
 
+// InitStderrLog 将日志输出到标准错误，用于文件日志无法初始化时的降级
+func InitStderrLog() {
+	slog.SetDefault(slog.New(NewContextHandler(newTextHandler(os.Stderr))))
+}
+
 // 添加前缀功能的便捷方法
 func WithPrefix(prefix string) *slog.Logger {
 	return slog.With("prefix", prefix)
@@ -0,0 +1,363 @@
+// Package metrics 提供 Prometheus 文本格式的指标采集与输出
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Collector 指标采集接口，Write 以 Prometheus 文本格式写出指标族
+type Collector interface {
+	Name() string
+	Write(w *bufio.Writer)
+}
+
+// Registry 指标注册表
+type Registry struct {
+	mu         sync.RWMutex
+	collectors map[string]Collector
+}
+
+// NewRegistry 创建空注册表
+func NewRegistry() *Registry {
+	return &Registry{collectors: map[string]Collector{}}
+}
+
+// Default 默认注册表，New* 系列函数创建的指标均注册于此
+var Default = NewRegistry()
+
+// Register 注册采集器，重名时返回错误
+func (reg *Registry) Register(c Collector) error {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	if _, ok := reg.collectors[c.Name()]; ok {
+		return fmt.Errorf("metric %q already registered", c.Name())
+	}
+	reg.collectors[c.Name()] = c
+	return nil
+}
+
+// MustRegister 注册采集器，重名时 panic
+func (reg *Registry) MustRegister(c Collector) {
+	if err := reg.Register(c); err != nil {
+		panic(err)
+	}
+}
+
+// Unregister 移除采集器
+func (reg *Registry) Unregister(name string) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	delete(reg.collectors, name)
+}
+
+// WriteTo 按名称顺序输出所有指标
+func (reg *Registry) WriteTo(w *bufio.Writer) {
+	reg.mu.RLock()
+	list := make([]Collector, 0, len(reg.collectors))
+	for _, c := range reg.collectors {
+		list = append(list, c)
+	}
+	reg.mu.RUnlock()
+
+	sort.Slice(list, func(i, j int) bool { return list[i].Name() < list[j].Name() })
+	for _, c := range list {
+		c.Write(w)
+	}
+}
+
+// Handler 输出注册表中的所有指标
+func (reg *Registry) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		bw := bufio.NewWriter(w)
+		reg.WriteTo(bw)
+		bw.Flush()
+	}
+}
+
+// Register 注册到默认注册表
+func Register(c Collector) error { return Default.Register(c) }
+
+// Handler 输出默认注册表中的所有指标
+func Handler() http.HandlerFunc { return Default.Handler() }
+
+// family 指标族的公共部分：名称、说明和标签
+type family struct {
+	name   string
+	help   string
+	typ    string
+	labels []string
+}
+
+func (f *family) Name() string { return f.name }
+
+func (f *family) writeHeader(w *bufio.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n", f.name, escapeHelp(f.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", f.name, f.typ)
+}
+
+func (f *family) key(values []string) string {
+	if len(values) != len(f.labels) {
+		panic(fmt.Sprintf("metric %q: expected %d label values, got %d", f.name, len(f.labels), len(values)))
+	}
+	return strings.Join(values, "\xff")
+}
+
+// labelString 生成 {k="v",...}，extra 为附加标签（如 le）
+func (f *family) labelString(values []string, extra ...string) string {
+	if len(f.labels) == 0 && len(extra) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, l := range f.labels {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, `%s="%s"`, l, escapeLabel(values[i]))
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		if b.Len() > 1 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, `%s="%s"`, extra[i], escapeLabel(extra[i+1]))
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// series 带标签值的单个时间序列
+type series struct {
+	values []string
+	value  float64
+}
+
+// Counter 单调递增计数器
+type Counter struct {
+	family
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+// NewCounter 创建计数器并注册到默认注册表
+func NewCounter(name, help string, labels ...string) *Counter {
+	c := &Counter{
+		family: family{name: name, help: help, typ: "counter", labels: labels},
+		series: map[string]*series{},
+	}
+	Default.MustRegister(c)
+	return c
+}
+
+// Inc 计数加 1
+func (c *Counter) Inc(labelValues ...string) { c.Add(1, labelValues...) }
+
+// Add 计数增加 v，v 必须非负
+func (c *Counter) Add(v float64, labelValues ...string) {
+	if v < 0 {
+		panic(fmt.Sprintf("counter %q cannot decrease", c.name))
+	}
+	k := c.key(labelValues)
+	c.mu.Lock()
+	s, ok := c.series[k]
+	if !ok {
+		s = &series{values: append([]string(nil), labelValues...)}
+		c.series[k] = s
+	}
+	s.value += v
+	c.mu.Unlock()
+}
+
+func (c *Counter) Write(w *bufio.Writer) {
+	writeSeries(w, &c.family, &c.mu, c.series)
+}
+
+// Gauge 可增可减的仪表盘
+type Gauge struct {
+	family
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+// NewGauge 创建仪表盘并注册到默认注册表
+func NewGauge(name, help string, labels ...string) *Gauge {
+	g := &Gauge{
+		family: family{name: name, help: help, typ: "gauge", labels: labels},
+		series: map[string]*series{},
+	}
+	Default.MustRegister(g)
+	return g
+}
+
+// Set 设置值
+func (g *Gauge) Set(v float64, labelValues ...string) {
+	g.update(labelValues, func(s *series) { s.value = v })
+}
+
+// Add 增加 v，v 可为负
+func (g *Gauge) Add(v float64, labelValues ...string) {
+	g.update(labelValues, func(s *series) { s.value += v })
+}
+
+// Inc 加 1
+func (g *Gauge) Inc(labelValues ...string) { g.Add(1, labelValues...) }
+
+// Dec 减 1
+func (g *Gauge) Dec(labelValues ...string) { g.Add(-1, labelValues...) }
+
+func (g *Gauge) update(labelValues []string, fn func(*series)) {
+	k := g.key(labelValues)
+	g.mu.Lock()
+	s, ok := g.series[k]
+	if !ok {
+		s = &series{values: append([]string(nil), labelValues...)}
+		g.series[k] = s
+	}
+	fn(s)
+	g.mu.Unlock()
+}
+
+func (g *Gauge) Write(w *bufio.Writer) {
+	writeSeries(w, &g.family, &g.mu, g.series)
+}
+
+// GaugeFunc 输出时调用函数取值的仪表盘
+type GaugeFunc struct {
+	family
+	fn func() float64
+}
+
+// NewGaugeFunc 创建函数型仪表盘并注册到默认注册表
+func NewGaugeFunc(name, help string, fn func() float64) *GaugeFunc {
+	g := &GaugeFunc{
+		family: family{name: name, help: help, typ: "gauge"},
+		fn:     fn,
+	}
+	Default.MustRegister(g)
+	return g
+}
+
+func (g *GaugeFunc) Write(w *bufio.Writer) {
+	g.writeHeader(w)
+	fmt.Fprintf(w, "%s %s\n", g.name, formatFloat(g.fn()))
+}
+
+// DefBuckets 默认的延迟分桶（秒）
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+type histSeries struct {
+	values []string
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// Histogram 直方图
+type Histogram struct {
+	family
+	buckets []float64
+	mu      sync.Mutex
+	series  map[string]*histSeries
+}
+
+// NewHistogram 创建直方图并注册到默认注册表，buckets 为空时使用 DefBuckets
+func NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	if len(buckets) == 0 {
+		buckets = DefBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+
+	h := &Histogram{
+		family:  family{name: name, help: help, typ: "histogram", labels: labels},
+		buckets: buckets,
+		series:  map[string]*histSeries{},
+	}
+	Default.MustRegister(h)
+	return h
+}
+
+// Observe 记录一次观测值
+func (h *Histogram) Observe(v float64, labelValues ...string) {
+	k := h.key(labelValues)
+	h.mu.Lock()
+	s, ok := h.series[k]
+	if !ok {
+		s = &histSeries{
+			values: append([]string(nil), labelValues...),
+			counts: make([]uint64, len(h.buckets)),
+		}
+		h.series[k] = s
+	}
+	for i, b := range h.buckets {
+		if v <= b {
+			s.counts[i]++
+		}
+	}
+	s.sum += v
+	s.count++
+	h.mu.Unlock()
+}
+
+func (h *Histogram) Write(w *bufio.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.writeHeader(w)
+	for _, k := range sortedKeys(h.series) {
+		s := h.series[k]
+		for i, b := range h.buckets {
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, h.labelString(s.values, "le", formatFloat(b)), s.counts[i])
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, h.labelString(s.values, "le", "+Inf"), s.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.name, h.labelString(s.values), formatFloat(s.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, h.labelString(s.values), s.count)
+	}
+}
+
+func writeSeries(w *bufio.Writer, f *family, mu *sync.Mutex, m map[string]*series) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	f.writeHeader(w)
+	for _, k := range sortedKeys(m) {
+		s := m[k]
+		fmt.Fprintf(w, "%s%s %s\n", f.name, f.labelString(s.values), formatFloat(s.value))
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string  { return helpEscaper.Replace(s) }
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
@@ -0,0 +1,74 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"runtime"
+	"scaffold/pkg/common"
+	"time"
+)
+
+var processStart = time.Now()
+
+func init() {
+	Default.MustRegister(goCollector{})
+	Default.MustRegister(&buildInfo{family: family{
+		name:   "app_build_info",
+		help:   "Build information of the running binary.",
+		typ:    "gauge",
+		labels: []string{"version", "go_version"},
+	}})
+	NewGaugeFunc("process_start_time_seconds", "Start time of the process since unix epoch in seconds.", func() float64 {
+		return float64(processStart.UnixNano()) / 1e9
+	})
+}
+
+// buildInfo 常量 1 的构建信息指标
+type buildInfo struct {
+	family
+}
+
+func (b *buildInfo) Write(w *bufio.Writer) {
+	b.writeHeader(w)
+	fmt.Fprintf(w, "%s%s 1\n", b.name, b.labelString([]string{common.Version, runtime.Version()}))
+}
+
+// goCollector 输出 Go 运行时指标，每次采集时读取 MemStats
+type goCollector struct{}
+
+func (goCollector) Name() string { return "go_" }
+
+func (goCollector) Write(w *bufio.Writer) {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+
+	gauges := []struct {
+		name, help string
+		value      float64
+	}{
+		{"go_goroutines", "Number of goroutines that currently exist.", float64(runtime.NumGoroutine())},
+		{"go_memstats_alloc_bytes", "Number of bytes allocated and still in use.", float64(ms.Alloc)},
+		{"go_memstats_heap_inuse_bytes", "Number of heap bytes that are in use.", float64(ms.HeapInuse)},
+		{"go_memstats_heap_objects", "Number of allocated objects.", float64(ms.HeapObjects)},
+		{"go_memstats_stack_inuse_bytes", "Number of bytes in use by the stack allocator.", float64(ms.StackInuse)},
+		{"go_memstats_sys_bytes", "Number of bytes obtained from system.", float64(ms.Sys)},
+		{"go_memstats_next_gc_bytes", "Number of heap bytes when next garbage collection will take place.", float64(ms.NextGC)},
+	}
+	for _, g := range gauges {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n%s %s\n", g.name, g.help, g.name, g.name, formatFloat(g.value))
+	}
+
+	counters := []struct {
+		name, help string
+		value      float64
+	}{
+		{"go_memstats_alloc_bytes_total", "Total number of bytes allocated, even if freed.", float64(ms.TotalAlloc)},
+		{"go_gc_cycles_total", "Number of completed GC cycles.", float64(ms.NumGC)},
+		{"go_gc_pause_seconds_total", "Total GC stop-the-world pause time in seconds.", float64(ms.PauseTotalNs) / 1e9},
+	}
+	for _, c := range counters {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n%s %s\n", c.name, c.help, c.name, c.name, formatFloat(c.value))
+	}
+
+	fmt.Fprintf(w, "# HELP go_info Information about the Go environment.\n# TYPE go_info gauge\ngo_info{version=%q} 1\n", runtime.Version())
+}
@@ -0,0 +1,146 @@
+// Package ratelimit 提供按键区分的令牌桶限流，长时间未使用的桶会被回收，桶数量有上限
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Result 一次请求的限流结果
+type Result struct {
+	Allowed    bool
+	Limit      int           // 桶容量
+	Remaining  int           // 剩余令牌数
+	Reset      time.Duration // 令牌恢复满桶所需时间
+	RetryAfter time.Duration // 被拒绝时下一个令牌可用的等待时间
+}
+
+// DefaultMaxKeys 默认最多保留的桶数
+const DefaultMaxKeys = 100_000
+
+// overflowKey 桶数量达到上限后，新出现的键共用这个桶
+const overflowKey = "\x00overflow"
+
+// Limiter 令牌桶限流器，每个键一个桶，并发安全
+type Limiter struct {
+	rate    float64 // 每秒补充的令牌数
+	burst   int
+	idle    time.Duration
+	maxKeys int
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// New 创建限流器：每 per 时间补充 limit 个令牌，桶容量为 burst（<=0 时等于 limit）
+func New(limit int, per time.Duration, burst int) *Limiter {
+	if burst <= 0 {
+		burst = limit
+	}
+	l := &Limiter{
+		rate:    float64(limit) / per.Seconds(),
+		burst:   burst,
+		maxKeys: DefaultMaxKeys,
+		buckets: make(map[string]*bucket),
+	}
+	// 桶补满后与新建桶等价，空闲超过补满时间即可回收，至少保留一分钟
+	l.idle = max(l.fill(0), time.Minute)
+	return l
+}
+
+// fill 从 tokens 个令牌补满桶所需的时间
+func (l *Limiter) fill(tokens float64) time.Duration {
+	return time.Duration((float64(l.burst) - tokens) / l.rate * float64(time.Second))
+}
+
+// SetMaxKeys 设置最多保留的桶数，<=0 时使用 DefaultMaxKeys。
+// 达到上限后新出现的键共用一个桶，伪造大量键的请求只能耗尽这个共享桶。
+func (l *Limiter) SetMaxKeys(n int) {
+	if n <= 0 {
+		n = DefaultMaxKeys
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.maxKeys = n
+}
+
+// Allow 为 key 消耗一个令牌
+func (l *Limiter) Allow(key string) Result {
+	return l.take(key, true)
+}
+
+// Peek 返回 key 当前的限流结果，不消耗令牌
+func (l *Limiter) Peek(key string) Result {
+	return l.take(key, false)
+}
+
+func (l *Limiter) take(key string, consume bool) Result {
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweepLocked(now, l.idle)
+
+	b, ok := l.buckets[key]
+	if !ok && len(l.buckets) >= l.maxKeys {
+		// 提前回收（最多每秒一次，避免每个新键都遍历全部桶），仍然满时使用共享桶
+		l.sweepLocked(now, time.Second)
+		if len(l.buckets) >= l.maxKeys {
+			key = overflowKey
+		}
+		b, ok = l.buckets[key]
+	}
+	if !ok {
+		if !consume {
+			// 只查询的键不建桶
+			return Result{Allowed: true, Limit: l.burst, Remaining: l.burst}
+		}
+		b = &bucket{tokens: float64(l.burst), last: now}
+		l.buckets[key] = b
+	} else {
+		elapsed := now.Sub(b.last).Seconds()
+		b.tokens = math.Min(float64(l.burst), b.tokens+elapsed*l.rate)
+		b.last = now
+	}
+
+	res := Result{Limit: l.burst}
+	if b.tokens >= 1 {
+		if consume {
+			b.tokens--
+		}
+		res.Allowed = true
+	} else {
+		res.RetryAfter = time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+	}
+	res.Remaining = int(b.tokens)
+	res.Reset = l.fill(b.tokens)
+	return res
+}
+
+// Len 当前保留的桶数
+func (l *Limiter) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.buckets)
+}
+
+// sweepLocked 距上次回收超过 interval 时回收空闲的桶，保证内存只与活跃键的数量相关
+func (l *Limiter) sweepLocked(now time.Time, interval time.Duration) {
+	if now.Sub(l.lastSweep) < interval {
+		return
+	}
+	l.lastSweep = now
+	for k, b := range l.buckets {
+		if now.Sub(b.last) >= l.idle {
+			delete(l.buckets, k)
+		}
+	}
+}
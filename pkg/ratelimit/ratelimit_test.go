@@ -0,0 +1,67 @@
+package ratelimit
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestAllow(t *testing.T) {
+	l := New(2, time.Hour, 3)
+	for i := range 3 {
+		res := l.Allow("a")
+		if !res.Allowed || res.Remaining != 2-i || res.Limit != 3 {
+			t.Fatalf("request %d: %+v", i, res)
+		}
+	}
+	res := l.Allow("a")
+	if res.Allowed || res.RetryAfter <= 0 {
+		t.Fatalf("over limit: %+v", res)
+	}
+	if !l.Allow("b").Allowed {
+		t.Fatal("keys must not share buckets")
+	}
+}
+
+func TestPeek(t *testing.T) {
+	l := New(1, time.Hour, 1)
+	if res := l.Peek("a"); !res.Allowed || res.Remaining != 1 {
+		t.Fatalf("peek new key: %+v", res)
+	}
+	if l.Len() != 0 {
+		t.Fatal("peek must not create a bucket")
+	}
+	l.Allow("a")
+	for range 2 {
+		if l.Peek("a").Allowed {
+			t.Fatal("peek after exhaustion should be denied")
+		}
+	}
+}
+
+func TestMaxKeys(t *testing.T) {
+	l := New(1, time.Hour, 1)
+	l.SetMaxKeys(10)
+
+	for i := range 10 {
+		if !l.Allow(strconv.Itoa(i)).Allowed {
+			t.Fatalf("key %d denied", i)
+		}
+	}
+	// 超出上限的新键共用一个桶，只有第一个请求能通过
+	if !l.Allow("new-1").Allowed {
+		t.Fatal("first overflow key should use the fresh shared bucket")
+	}
+	for i := range 100 {
+		if l.Allow("spoofed-" + strconv.Itoa(i)).Allowed {
+			t.Fatalf("overflow key %d allowed, shared bucket should be exhausted", i)
+		}
+	}
+	if n := l.Len(); n > 11 {
+		t.Fatalf("buckets = %d, want at most max keys plus the shared bucket", n)
+	}
+	// 已有的键不受影响
+	if l.Allow("0").Allowed {
+		t.Fatal("existing key should keep its own exhausted bucket")
+	}
+}
@@ -0,0 +1,216 @@
+// Package sse 将事件总线上的事件以 Server-Sent Events 推送给浏览器
+package sse
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"scaffold/pkg/common/problem"
+	"scaffold/pkg/eventbus"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Options 推送选项，零值字段使用默认值
+type Options struct {
+	// Topics 允许客户端订阅的主题模式，客户端可通过 ?topics=a,b 进一步筛选，默认全部
+	Topics []string
+	// ClientBuffer 每个客户端的事件缓冲，客户端处理不过来时新事件会被丢弃
+	ClientBuffer int
+	// ReplaySize 断线重连时可补发的最近事件数
+	ReplaySize int
+	// ReplayWindow 可补发事件的最长时间
+	ReplayWindow time.Duration
+	// Heartbeat 心跳间隔，用于保持连接和发现断开的客户端
+	Heartbeat time.Duration
+	// Retry 建议客户端的重连间隔
+	Retry time.Duration
+}
+
+func (o *Options) setDefaults() {
+	if len(o.Topics) == 0 {
+		o.Topics = []string{"*"}
+	}
+	if o.ClientBuffer <= 0 {
+		o.ClientBuffer = 64
+	}
+	if o.ReplaySize <= 0 {
+		o.ReplaySize = 256
+	}
+	if o.ReplayWindow <= 0 {
+		o.ReplayWindow = 5 * time.Minute
+	}
+	if o.Heartbeat <= 0 {
+		o.Heartbeat = 15 * time.Second
+	}
+	if o.Retry <= 0 {
+		o.Retry = 3 * time.Second
+	}
+}
+
+// Broker 订阅事件总线并维护补发窗口，同时作为 SSE 的 http.Handler
+type Broker struct {
+	bus  *eventbus.EventBus
+	opts Options
+	sub  *eventbus.Subscription
+
+	mu      sync.RWMutex
+	history []eventbus.Event
+}
+
+// NewBroker 创建推送处理器，不再使用时调用 Close
+func NewBroker(bus *eventbus.EventBus, opts Options) *Broker {
+	opts.setDefaults()
+	b := &Broker{
+		bus:  bus,
+		opts: opts,
+	}
+	// 在 Emit 中同步记录，客户端订阅之前发出的事件一定已经在补发窗口中
+	b.sub = bus.SubscribeFunc(b.record, opts.Topics...)
+	return b
+}
+
+// Close 停止记录补发事件
+func (b *Broker) Close() {
+	b.sub.Unsubscribe()
+}
+
+// record 记录最近的事件用于 Last-Event-ID 补发
+func (b *Broker) record(ev eventbus.Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.history = append(b.history, ev)
+	if n := len(b.history) - b.opts.ReplaySize; n > 0 {
+		b.history = append(b.history[:0], b.history[n:]...)
+	}
+}
+
+// replay 返回 ID 大于 lastID 且仍在补发窗口内的事件
+func (b *Broker) replay(lastID uint64, sub *eventbus.Subscription) []eventbus.Event {
+	cutoff := time.Now().Add(-b.opts.ReplayWindow)
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	var out []eventbus.Event
+	for _, ev := range b.history {
+		if ev.ID > lastID && ev.Time.After(cutoff) && sub.Matches(ev.Topic) {
+			out = append(out, ev)
+		}
+	}
+	return out
+}
+
+func (b *Broker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	topics := b.clientTopics(r)
+	if len(topics) == 0 {
+		problem.Error(w, r, http.StatusBadRequest, "no permitted topics requested")
+		return
+	}
+
+	// 先订阅再读取补发窗口：订阅之前发出的事件已同步记录在补发窗口中，之后的进入订阅，
+	// 两者重叠的部分依靠事件 ID 去重
+	sub := b.bus.SubscribeTopics(b.opts.ClientBuffer, topics...)
+	defer sub.Unsubscribe()
+
+	h := w.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("Connection", "keep-alive")
+	h.Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "retry: %d\n\n", b.opts.Retry.Milliseconds())
+
+	rc := http.NewResponseController(w)
+	if err := rc.Flush(); err != nil {
+		slog.Warn("sse: response writer does not support flushing", "error", err)
+		return
+	}
+
+	var sent uint64
+	if lastID, err := strconv.ParseUint(lastEventID(r), 10, 64); err == nil {
+		for _, ev := range b.replay(lastID, sub) {
+			if err := writeEvent(w, ev); err != nil {
+				return
+			}
+			sent = ev.ID
+		}
+	}
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(b.opts.Heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case ev, ok := <-sub.C():
+			if !ok {
+				return
+			}
+			if ev.ID <= sent {
+				continue
+			}
+			if err := writeEvent(w, ev); err != nil {
+				return
+			}
+			sent = ev.ID
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// clientTopics 取客户端请求的主题中被允许的部分，未指定时使用全部允许的主题
+func (b *Broker) clientTopics(r *http.Request) []string {
+	q := r.URL.Query().Get("topics")
+	if q == "" {
+		return b.opts.Topics
+	}
+	var out []string
+	for _, t := range strings.Split(q, ",") {
+		t = strings.TrimSpace(t)
+		if t != "" && b.permitted(t) {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// permitted 客户端主题需落在允许的主题模式内
+func (b *Broker) permitted(topic string) bool {
+	for _, p := range b.opts.Topics {
+		if p == "*" || eventbus.MatchTopic(p, strings.TrimSuffix(topic, "*")) {
+			return true
+		}
+	}
+	return false
+}
+
+// lastEventID 浏览器重连时通过请求头携带，也允许通过查询参数传递
+func lastEventID(r *http.Request) string {
+	if id := r.Header.Get("Last-Event-ID"); id != "" {
+		return id
+	}
+	return r.URL.Query().Get("last_event_id")
+}
+
+func writeEvent(w http.ResponseWriter, ev eventbus.Event) error {
+	data, err := json.Marshal(ev.Data)
+	if err != nil {
+		slog.Warn("sse: marshal event failed", "topic", ev.Topic, "error", err)
+		return nil
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", ev.ID, ev.Topic, data)
+	return err
+}
@@ -0,0 +1,67 @@
+package sse
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"scaffold/pkg/eventbus"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestReplayNoGap 订阅前刚发出的事件必须已经在补发窗口中
+func TestReplayNoGap(t *testing.T) {
+	bus := eventbus.New(1)
+	b := NewBroker(bus, Options{ReplaySize: 8})
+	defer b.Close()
+
+	for i := range 1000 {
+		ev := bus.Emit("device.update", i)
+		sub := bus.SubscribeTopics(1, "*")
+		got := b.replay(ev.ID-1, sub)
+		sub.Unsubscribe()
+		if len(got) != 1 || got[0].ID != ev.ID {
+			t.Fatalf("event %d missing from replay: %v", ev.ID, got)
+		}
+	}
+	if n := len(b.history); n != 8 {
+		t.Fatalf("history = %d, want replay size 8", n)
+	}
+}
+
+func TestServeReplay(t *testing.T) {
+	bus := eventbus.New(1)
+	b := NewBroker(bus, Options{Topics: []string{"device.*"}})
+	defer b.Close()
+
+	bus.Emit("device.a", 1)
+	bus.Emit("other", 2)
+	bus.Emit("device.b", 3)
+
+	srv := httptest.NewServer(b)
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	req.Header.Set("Last-Event-ID", "1")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	sc := bufio.NewScanner(resp.Body)
+	var ids []string
+	for sc.Scan() && len(ids) < 1 {
+		if id, ok := strings.CutPrefix(sc.Text(), "id: "); ok {
+			ids = append(ids, id)
+		}
+	}
+	// 事件 1 已被客户端收到，事件 2 不在允许的主题内
+	if len(ids) != 1 || ids[0] != "3" {
+		t.Fatalf("replayed ids = %v, want [3]", ids)
+	}
+}
@@ -0,0 +1,98 @@
+package tlsutil
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// 加密套件策略
+const (
+	// PolicyDefault 使用 Go 标准库默认套件
+	PolicyDefault = "default"
+	// PolicyIntermediate 仅允许支持前向保密的 AEAD 套件
+	PolicyIntermediate = "intermediate"
+	// PolicyModern 仅允许 TLS 1.3
+	PolicyModern = "modern"
+)
+
+// intermediateSuites TLS 1.2 下允许的套件，TLS 1.3 套件由标准库固定
+var intermediateSuites = []uint16{
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256,
+}
+
+// ParseVersion 解析 "1.0"～"1.3" 形式的 TLS 版本，空字符串默认为 1.2
+func ParseVersion(v string) (uint16, error) {
+	switch v {
+	case "", "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	case "1.1":
+		return tls.VersionTLS11, nil
+	case "1.0":
+		return tls.VersionTLS10, nil
+	}
+	return 0, fmt.Errorf("unsupported tls version %q", v)
+}
+
+// ServerConfig 根据最低版本和套件策略生成服务端 TLS 配置
+func ServerConfig(reloader *CertReloader, minVersion, policy string) (*tls.Config, error) {
+	version, err := ParseVersion(minVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &tls.Config{
+		MinVersion:     version,
+		GetCertificate: reloader.GetCertificate,
+	}
+
+	switch policy {
+	case "", PolicyDefault:
+	case PolicyIntermediate:
+		cfg.CipherSuites = intermediateSuites
+	case PolicyModern:
+		cfg.MinVersion = tls.VersionTLS13
+	default:
+		return nil, fmt.Errorf("unsupported cipher policy %q", policy)
+	}
+	return cfg, nil
+}
+
+// 客户端证书校验模式
+const (
+	ClientAuthRequired = "required"
+	ClientAuthOptional = "optional"
+)
+
+// EnableClientAuth 启用双向 TLS：使用 caFile 中的 CA 校验客户端证书。
+// required 模式下无证书的连接会在握手阶段被拒绝，optional 模式下仅校验提供了的证书。
+func EnableClientAuth(cfg *tls.Config, caFile, mode string) error {
+	pem, err := os.ReadFile(caFile)
+	if err != nil {
+		return err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return errors.New("no certificates found in " + caFile)
+	}
+	cfg.ClientCAs = pool
+
+	switch mode {
+	case "", ClientAuthRequired:
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	case ClientAuthOptional:
+		cfg.ClientAuth = tls.VerifyClientCertIfGiven
+	default:
+		return fmt.Errorf("unsupported client auth mode %q", mode)
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+// Package tlsutil 提供证书热加载、TLS 配置和自签名证书生成
+package tlsutil
+
+import (
+	"crypto/tls"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// checkInterval 两次检查证书文件变化的最小间隔
+const checkInterval = 5 * time.Second
+
+// CertReloader 证书热加载器，握手时按间隔检查证书文件，变化后自动重新加载
+type CertReloader struct {
+	certFile string
+	keyFile  string
+
+	mu        sync.RWMutex
+	cert      *tls.Certificate
+	certStamp fileStamp
+	keyStamp  fileStamp
+	lastCheck time.Time
+}
+
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// NewCertReloader 加载证书，首次加载失败时返回错误
+func NewCertReloader(certFile, keyFile string) (*CertReloader, error) {
+	cr := &CertReloader{certFile: certFile, keyFile: keyFile}
+	if err := cr.Reload(); err != nil {
+		return nil, err
+	}
+	return cr, nil
+}
+
+// Reload 立即重新加载证书，失败时保留旧证书
+func (cr *CertReloader) Reload() error {
+	certStamp, err := stat(cr.certFile)
+	if err != nil {
+		return err
+	}
+	keyStamp, err := stat(cr.keyFile)
+	if err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(cr.certFile, cr.keyFile)
+	if err != nil {
+		return err
+	}
+
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+	cr.cert = &cert
+	cr.certStamp, cr.keyStamp = certStamp, keyStamp
+	cr.lastCheck = time.Now()
+	return nil
+}
+
+// GetCertificate 用于 tls.Config.GetCertificate
+func (cr *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	cr.maybeReload()
+
+	cr.mu.RLock()
+	defer cr.mu.RUnlock()
+	return cr.cert, nil
+}
+
+// maybeReload 距上次检查超过间隔且文件有变化时重新加载
+func (cr *CertReloader) maybeReload() {
+	cr.mu.Lock()
+	if time.Since(cr.lastCheck) < checkInterval {
+		cr.mu.Unlock()
+		return
+	}
+	cr.lastCheck = time.Now()
+	oldCert, oldKey := cr.certStamp, cr.keyStamp
+	cr.mu.Unlock()
+
+	certStamp, err1 := stat(cr.certFile)
+	keyStamp, err2 := stat(cr.keyFile)
+	if err1 != nil || err2 != nil || (certStamp == oldCert && keyStamp == oldKey) {
+		return
+	}
+
+	if err := cr.Reload(); err != nil {
+		// 证书和私钥可能尚未全部写完，下次检查再试
+		slog.Warn("reload tls certificate failed, keep the old one", "cert", cr.certFile, "error", err)
+		return
+	}
+	slog.Info("tls certificate reloaded", "cert", cr.certFile)
+}
+
+func stat(name string) (fileStamp, error) {
+	info, err := os.Stat(name)
+	if err != nil {
+		return fileStamp{}, err
+	}
+	return fileStamp{modTime: info.ModTime(), size: info.Size()}, nil
+}
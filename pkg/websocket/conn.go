@@ -0,0 +1,317 @@
+// Package websocket 实现 RFC 6455 服务端连接以及基于事件总线的主题订阅中心
+package websocket
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"scaffold/pkg/common/problem"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// 消息类型
+const (
+	TextMessage   = 1
+	BinaryMessage = 2
+	CloseMessage  = 8
+	PingMessage   = 9
+	PongMessage   = 10
+
+	continuationFrame = 0
+)
+
+// 关闭码
+const (
+	CloseNormal          = 1000
+	CloseGoingAway       = 1001
+	CloseProtocolError   = 1002
+	CloseUnsupportedData = 1003
+	CloseInvalidPayload  = 1007
+	ClosePolicyViolation = 1008
+	CloseMessageTooBig   = 1009
+	CloseInternalError   = 1011
+	CloseTryAgainLater   = 1013
+)
+
+const handshakeGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// CloseError 对端发送关闭帧或因协议错误关闭
+type CloseError struct {
+	Code int
+	Text string
+}
+
+func (e *CloseError) Error() string {
+	return fmt.Sprintf("websocket closed: %d %s", e.Code, e.Text)
+}
+
+// ErrBadHandshake 不是合法的 WebSocket 升级请求
+var ErrBadHandshake = errors.New("websocket: bad handshake")
+
+// Conn WebSocket 连接。读操作只能在一个 goroutine 中进行，写操作并发安全。
+type Conn struct {
+	conn net.Conn
+	br   *bufio.Reader
+
+	writeMu sync.Mutex
+	closed  bool
+
+	maxMessageSize int64
+	pongHandler    func()
+}
+
+// Upgrade 校验握手请求并接管连接。失败时已向客户端写出错误响应。
+func Upgrade(w http.ResponseWriter, r *http.Request, maxMessageSize int64) (*Conn, error) {
+	if r.Method != http.MethodGet ||
+		!headerContains(r.Header, "Connection", "upgrade") ||
+		!headerContains(r.Header, "Upgrade", "websocket") {
+		problem.Error(w, r, http.StatusUpgradeRequired, "websocket upgrade required")
+		return nil, ErrBadHandshake
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		problem.Error(w, r, http.StatusBadRequest, "unsupported websocket version")
+		return nil, ErrBadHandshake
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if decoded, err := base64.StdEncoding.DecodeString(key); err != nil || len(decoded) != 16 {
+		problem.Error(w, r, http.StatusBadRequest, "invalid Sec-WebSocket-Key")
+		return nil, ErrBadHandshake
+	}
+
+	netConn, brw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		problem.Error(w, r, http.StatusInternalServerError, "websocket hijack unsupported")
+		return nil, err
+	}
+	// 清除服务端设置的读写超时，由连接自行管理
+	netConn.SetDeadline(time.Time{})
+
+	resp := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + acceptKey(key) + "\r\n\r\n"
+	if _, err := brw.WriteString(resp); err != nil {
+		netConn.Close()
+		return nil, err
+	}
+	if err := brw.Flush(); err != nil {
+		netConn.Close()
+		return nil, err
+	}
+
+	return &Conn{
+		conn:           netConn,
+		br:             brw.Reader,
+		maxMessageSize: maxMessageSize,
+	}, nil
+}
+
+func acceptKey(key string) string {
+	h := sha1.Sum([]byte(key + handshakeGUID))
+	return base64.StdEncoding.EncodeToString(h[:])
+}
+
+func headerContains(h http.Header, name, token string) bool {
+	for _, v := range h.Values(name) {
+		for _, part := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// RemoteAddr 对端地址
+func (c *Conn) RemoteAddr() net.Addr { return c.conn.RemoteAddr() }
+
+// SetReadDeadline 设置读超时
+func (c *Conn) SetReadDeadline(t time.Time) error { return c.conn.SetReadDeadline(t) }
+
+// SetPongHandler 收到 pong 时回调，通常用于延长读超时
+func (c *Conn) SetPongHandler(fn func()) { c.pongHandler = fn }
+
+// ReadMessage 读取一条完整的数据消息，自动处理 ping/pong 和分片
+func (c *Conn) ReadMessage() (messageType int, data []byte, err error) {
+	var (
+		msgType int
+		buf     []byte
+	)
+	for {
+		fin, opcode, payload, err := c.readFrame()
+		if err != nil {
+			return 0, nil, err
+		}
+
+		switch opcode {
+		case PingMessage:
+			if err := c.WriteControl(PongMessage, payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case PongMessage:
+			if c.pongHandler != nil {
+				c.pongHandler()
+			}
+			continue
+		case CloseMessage:
+			code, text := CloseNormal, ""
+			if len(payload) >= 2 {
+				code = int(binary.BigEndian.Uint16(payload))
+				text = string(payload[2:])
+			}
+			c.WriteClose(code, "")
+			return 0, nil, &CloseError{Code: code, Text: text}
+		case TextMessage, BinaryMessage:
+			if msgType != 0 {
+				return 0, nil, c.fail(CloseProtocolError, "unexpected data frame")
+			}
+			msgType = opcode
+		case continuationFrame:
+			if msgType == 0 {
+				return 0, nil, c.fail(CloseProtocolError, "unexpected continuation frame")
+			}
+		default:
+			return 0, nil, c.fail(CloseProtocolError, "unknown opcode")
+		}
+
+		if c.maxMessageSize > 0 && int64(len(buf)+len(payload)) > c.maxMessageSize {
+			return 0, nil, c.fail(CloseMessageTooBig, "message too big")
+		}
+		buf = append(buf, payload...)
+		if fin {
+			if msgType == TextMessage && !utf8.Valid(buf) {
+				return 0, nil, c.fail(CloseInvalidPayload, "invalid utf-8")
+			}
+			return msgType, buf, nil
+		}
+	}
+}
+
+// readFrame 读取单个帧，客户端帧必须带掩码
+func (c *Conn) readFrame() (fin bool, opcode int, payload []byte, err error) {
+	var head [2]byte
+	if _, err = io.ReadFull(c.br, head[:]); err != nil {
+		return
+	}
+	fin = head[0]&0x80 != 0
+	if head[0]&0x70 != 0 {
+		return false, 0, nil, c.fail(CloseProtocolError, "reserved bits set")
+	}
+	opcode = int(head[0] & 0x0f)
+	masked := head[1]&0x80 != 0
+	length := int64(head[1] & 0x7f)
+
+	if !masked {
+		return false, 0, nil, c.fail(CloseProtocolError, "client frame not masked")
+	}
+	if opcode >= CloseMessage && (!fin || length > 125) {
+		return false, 0, nil, c.fail(CloseProtocolError, "invalid control frame")
+	}
+
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err = io.ReadFull(c.br, ext[:]); err != nil {
+			return
+		}
+		length = int64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err = io.ReadFull(c.br, ext[:]); err != nil {
+			return
+		}
+		length = int64(binary.BigEndian.Uint64(ext[:]))
+	}
+	if length < 0 || (c.maxMessageSize > 0 && length > c.maxMessageSize) {
+		return false, 0, nil, c.fail(CloseMessageTooBig, "frame too big")
+	}
+
+	var mask [4]byte
+	if _, err = io.ReadFull(c.br, mask[:]); err != nil {
+		return
+	}
+	payload = make([]byte, length)
+	if _, err = io.ReadFull(c.br, payload); err != nil {
+		return
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return fin, opcode, payload, nil
+}
+
+// fail 发送关闭帧并返回对应错误
+func (c *Conn) fail(code int, text string) error {
+	c.WriteClose(code, text)
+	return &CloseError{Code: code, Text: text}
+}
+
+// WriteMessage 写出一条数据消息
+func (c *Conn) WriteMessage(messageType int, data []byte, deadline time.Time) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if c.closed {
+		return net.ErrClosed
+	}
+	c.conn.SetWriteDeadline(deadline)
+	return c.writeFrame(messageType, data)
+}
+
+// WriteControl 写出控制帧（ping/pong）
+func (c *Conn) WriteControl(messageType int, data []byte) error {
+	return c.WriteMessage(messageType, data, time.Now().Add(5*time.Second))
+}
+
+// WriteClose 发送关闭帧，之后不能再写出消息
+func (c *Conn) WriteClose(code int, text string) error {
+	payload := make([]byte, 2, 2+len(text))
+	binary.BigEndian.PutUint16(payload, uint16(code))
+	payload = append(payload, text...)
+	if len(payload) > 125 {
+		payload = payload[:125]
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	c.conn.SetWriteDeadline(time.Now().Add(time.Second))
+	return c.writeFrame(CloseMessage, payload)
+}
+
+func (c *Conn) writeFrame(opcode int, data []byte) error {
+	header := make([]byte, 0, 10)
+	header = append(header, 0x80|byte(opcode))
+	switch n := len(data); {
+	case n <= 125:
+		header = append(header, byte(n))
+	case n <= 0xffff:
+		header = append(header, 126)
+		header = binary.BigEndian.AppendUint16(header, uint16(n))
+	default:
+		header = append(header, 127)
+		header = binary.BigEndian.AppendUint64(header, uint64(n))
+	}
+
+	bufs := net.Buffers{header, data}
+	_, err := bufs.WriteTo(c.conn)
+	return err
+}
+
+// Close 关闭底层连接
+func (c *Conn) Close() error {
+	return c.conn.Close()
+}
@@ -0,0 +1,314 @@
+package websocket
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// clientFrame 构造客户端帧，masked 为 false 时不带掩码
+func clientFrame(fin bool, opcode int, payload []byte, masked bool) []byte {
+	var b []byte
+	first := byte(opcode)
+	if fin {
+		first |= 0x80
+	}
+	b = append(b, first)
+	maskBit := byte(0)
+	if masked {
+		maskBit = 0x80
+	}
+	switch n := len(payload); {
+	case n <= 125:
+		b = append(b, maskBit|byte(n))
+	case n <= 0xffff:
+		b = append(b, maskBit|126)
+		b = binary.BigEndian.AppendUint16(b, uint16(n))
+	default:
+		b = append(b, maskBit|127)
+		b = binary.BigEndian.AppendUint64(b, uint64(n))
+	}
+	if !masked {
+		return append(b, payload...)
+	}
+	mask := [4]byte{0x12, 0x34, 0x56, 0x78}
+	b = append(b, mask[:]...)
+	for i, c := range payload {
+		b = append(b, c^mask[i%4])
+	}
+	return b
+}
+
+// serverFrame 解析服务端写出的单个帧（不带掩码）
+func serverFrame(r io.Reader) (fin bool, opcode int, payload []byte, err error) {
+	var head [2]byte
+	if _, err = io.ReadFull(r, head[:]); err != nil {
+		return
+	}
+	if head[1]&0x80 != 0 {
+		return false, 0, nil, errors.New("server frame is masked")
+	}
+	length := uint64(head[1] & 0x7f)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err = io.ReadFull(r, ext[:]); err != nil {
+			return
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err = io.ReadFull(r, ext[:]); err != nil {
+			return
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+	payload = make([]byte, length)
+	if _, err = io.ReadFull(r, payload); err != nil {
+		return
+	}
+	return head[0]&0x80 != 0, int(head[0] & 0x0f), payload, nil
+}
+
+// pipeConn 返回服务端连接和客户端一侧的 net.Conn
+func pipeConn(maxMessageSize int64) (*Conn, net.Conn) {
+	server, client := net.Pipe()
+	return &Conn{conn: server, br: bufio.NewReader(server), maxMessageSize: maxMessageSize}, client
+}
+
+func TestReadMessage(t *testing.T) {
+	long := bytes.Repeat([]byte("a"), 300)
+	huge := bytes.Repeat([]byte("b"), 70000)
+	// "é" 拆成两个分片，单个分片不是合法 UTF-8
+	e := []byte("é")
+
+	tests := []struct {
+		name    string
+		frames  [][]byte
+		max     int64
+		typ     int
+		data    []byte
+		code    int   // 期望的关闭码，0 表示读取成功
+		replies []int // 服务端应写出的帧类型
+	}{
+		{name: "text", frames: [][]byte{clientFrame(true, TextMessage, []byte("hello"), true)},
+			typ: TextMessage, data: []byte("hello")},
+		{name: "binary", frames: [][]byte{clientFrame(true, BinaryMessage, []byte{0, 1, 2}, true)},
+			typ: BinaryMessage, data: []byte{0, 1, 2}},
+		{name: "empty", frames: [][]byte{clientFrame(true, TextMessage, nil, true)},
+			typ: TextMessage, data: nil},
+		{name: "16-bit length", frames: [][]byte{clientFrame(true, BinaryMessage, long, true)},
+			typ: BinaryMessage, data: long},
+		{name: "64-bit length", frames: [][]byte{clientFrame(true, BinaryMessage, huge, true)},
+			typ: BinaryMessage, data: huge},
+		{name: "fragmented", frames: [][]byte{
+			clientFrame(false, TextMessage, []byte("hel"), true),
+			clientFrame(false, continuationFrame, []byte("l"), true),
+			clientFrame(true, continuationFrame, []byte("o"), true),
+		}, typ: TextMessage, data: []byte("hello")},
+		{name: "utf-8 split across fragments", frames: [][]byte{
+			clientFrame(false, TextMessage, e[:1], true),
+			clientFrame(true, continuationFrame, e[1:], true),
+		}, typ: TextMessage, data: e},
+		{name: "ping between fragments", frames: [][]byte{
+			clientFrame(false, TextMessage, []byte("a"), true),
+			clientFrame(true, PingMessage, []byte("p"), true),
+			clientFrame(true, continuationFrame, []byte("b"), true),
+		}, typ: TextMessage, data: []byte("ab"), replies: []int{PongMessage}},
+
+		{name: "unmasked", frames: [][]byte{clientFrame(true, TextMessage, []byte("x"), false)},
+			code: CloseProtocolError, replies: []int{CloseMessage}},
+		{name: "reserved bits", frames: [][]byte{func() []byte {
+			f := clientFrame(true, TextMessage, []byte("x"), true)
+			f[0] |= 0x40
+			return f
+		}()}, code: CloseProtocolError, replies: []int{CloseMessage}},
+		{name: "fragmented control frame", frames: [][]byte{clientFrame(false, PingMessage, nil, true)},
+			code: CloseProtocolError, replies: []int{CloseMessage}},
+		{name: "control frame too long", frames: [][]byte{clientFrame(true, PingMessage, long[:126], true)},
+			code: CloseProtocolError, replies: []int{CloseMessage}},
+		{name: "continuation without start", frames: [][]byte{clientFrame(true, continuationFrame, []byte("x"), true)},
+			code: CloseProtocolError, replies: []int{CloseMessage}},
+		{name: "data frame inside fragmented message", frames: [][]byte{
+			clientFrame(false, TextMessage, []byte("a"), true),
+			clientFrame(true, TextMessage, []byte("b"), true),
+		}, code: CloseProtocolError, replies: []int{CloseMessage}},
+		{name: "unknown opcode", frames: [][]byte{clientFrame(true, 3, []byte("x"), true)},
+			code: CloseProtocolError, replies: []int{CloseMessage}},
+		{name: "invalid utf-8", frames: [][]byte{clientFrame(true, TextMessage, []byte{0xff, 0xfe}, true)},
+			code: CloseInvalidPayload, replies: []int{CloseMessage}},
+		{name: "frame too big", max: 100, frames: [][]byte{clientFrame(true, BinaryMessage, long, true)},
+			code: CloseMessageTooBig, replies: []int{CloseMessage}},
+		{name: "message too big across fragments", max: 400, frames: [][]byte{
+			clientFrame(false, BinaryMessage, long, true),
+			clientFrame(true, continuationFrame, long, true),
+		}, code: CloseMessageTooBig, replies: []int{CloseMessage}},
+		{name: "close from client", frames: [][]byte{
+			clientFrame(true, CloseMessage, append([]byte{0x03, 0xe9}, "bye"...), true),
+		}, code: CloseGoingAway, replies: []int{CloseMessage}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, client := pipeConn(tt.max)
+			defer c.Close()
+			client.SetDeadline(time.Now().Add(5 * time.Second))
+
+			// 写入帧与读取服务端响应并行进行，net.Pipe 没有缓冲
+			go func() {
+				for _, f := range tt.frames {
+					if _, err := client.Write(f); err != nil {
+						return
+					}
+				}
+			}()
+			type frame struct {
+				opcode  int
+				payload []byte
+				err     error
+			}
+			replies := make(chan frame, len(tt.replies))
+			go func() {
+				for range tt.replies {
+					_, op, p, err := serverFrame(client)
+					replies <- frame{op, p, err}
+				}
+			}()
+
+			typ, data, err := c.ReadMessage()
+			if tt.code == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if typ != tt.typ || !bytes.Equal(data, tt.data) {
+					t.Fatalf("message = %d %q, want %d %q", typ, data, tt.typ, tt.data)
+				}
+			} else {
+				var ce *CloseError
+				if !errors.As(err, &ce) || ce.Code != tt.code {
+					t.Fatalf("err = %v, want close code %d", err, tt.code)
+				}
+			}
+
+			for _, want := range tt.replies {
+				f := <-replies
+				if f.err != nil {
+					t.Fatalf("read reply: %v", f.err)
+				}
+				if f.opcode != want {
+					t.Fatalf("reply opcode = %d, want %d", f.opcode, want)
+				}
+				if want == CloseMessage {
+					if code := int(binary.BigEndian.Uint16(f.payload)); code != tt.code {
+						t.Fatalf("close frame code = %d, want %d", code, tt.code)
+					}
+				}
+				if want == PongMessage && string(f.payload) != "p" {
+					t.Fatalf("pong payload = %q", f.payload)
+				}
+			}
+		})
+	}
+}
+
+func TestReadMessageCloseText(t *testing.T) {
+	c, client := pipeConn(0)
+	defer c.Close()
+	go client.Write(clientFrame(true, CloseMessage, append([]byte{0x03, 0xe8}, "done"...), true))
+	go io.Copy(io.Discard, client)
+
+	_, _, err := c.ReadMessage()
+	var ce *CloseError
+	if !errors.As(err, &ce) || ce.Code != CloseNormal || ce.Text != "done" {
+		t.Fatalf("err = %v", err)
+	}
+	// 收到关闭帧后不能再写出消息
+	if err := c.WriteMessage(TextMessage, []byte("x"), time.Time{}); !errors.Is(err, net.ErrClosed) {
+		t.Fatalf("write after close = %v", err)
+	}
+}
+
+func TestPongHandler(t *testing.T) {
+	c, client := pipeConn(0)
+	defer c.Close()
+	pongs := 0
+	c.SetPongHandler(func() { pongs++ })
+	go func() {
+		client.Write(clientFrame(true, PongMessage, nil, true))
+		client.Write(clientFrame(true, TextMessage, []byte("x"), true))
+	}()
+	if _, _, err := c.ReadMessage(); err != nil {
+		t.Fatal(err)
+	}
+	if pongs != 1 {
+		t.Fatalf("pong handler called %d times", pongs)
+	}
+}
+
+func TestWriteFrame(t *testing.T) {
+	tests := []struct {
+		name   string
+		opcode int
+		size   int
+		header []byte
+	}{
+		{"empty", TextMessage, 0, []byte{0x81, 0}},
+		{"7-bit", BinaryMessage, 125, []byte{0x82, 125}},
+		{"16-bit min", BinaryMessage, 126, []byte{0x82, 126, 0, 126}},
+		{"16-bit max", BinaryMessage, 0xffff, []byte{0x82, 126, 0xff, 0xff}},
+		{"64-bit", BinaryMessage, 0x10000, []byte{0x82, 127, 0, 0, 0, 0, 0, 1, 0, 0}},
+		{"ping", PingMessage, 4, []byte{0x89, 4}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, client := pipeConn(0)
+			defer c.Close()
+			data := bytes.Repeat([]byte("z"), tt.size)
+
+			got := make(chan []byte, 1)
+			go func() {
+				b := make([]byte, len(tt.header)+tt.size)
+				io.ReadFull(client, b)
+				got <- b
+				// net.Pipe 的空写入也要等待读取方
+				io.Copy(io.Discard, client)
+			}()
+			if err := c.WriteMessage(tt.opcode, data, time.Now().Add(5*time.Second)); err != nil {
+				t.Fatal(err)
+			}
+			b := <-got
+			if !bytes.Equal(b[:len(tt.header)], tt.header) {
+				t.Fatalf("header = % x, want % x", b[:len(tt.header)], tt.header)
+			}
+			if !bytes.Equal(b[len(tt.header):], data) {
+				t.Fatal("payload mismatch")
+			}
+		})
+	}
+}
+
+func TestWriteCloseTruncatesReason(t *testing.T) {
+	c, client := pipeConn(0)
+	defer c.Close()
+	go c.WriteClose(CloseNormal, strings.Repeat("r", 200))
+
+	fin, op, payload, err := serverFrame(client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !fin || op != CloseMessage || len(payload) != 125 {
+		t.Fatalf("close frame fin=%v opcode=%d len=%d", fin, op, len(payload))
+	}
+	if code := binary.BigEndian.Uint16(payload); code != CloseNormal {
+		t.Fatalf("code = %d", code)
+	}
+	// 重复关闭不再写出
+	if err := c.WriteClose(CloseNormal, ""); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,316 @@
+package websocket
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"scaffold/pkg/common/problem"
+	"scaffold/pkg/eventbus"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Options 订阅中心选项，零值字段使用默认值
+type Options struct {
+	// Topics 允许客户端订阅的主题模式，默认全部
+	Topics []string
+	// Commands 允许客户端发布的主题模式，默认不允许发布
+	Commands []string
+	// SendQueue 每个连接的发送队列长度，队列满时视为慢消费者并断开连接
+	SendQueue int
+	// MaxMessageSize 客户端单条消息的最大字节数
+	MaxMessageSize int64
+	// PingInterval 服务端发送 ping 的间隔，超过两个间隔未收到任何数据则断开
+	PingInterval time.Duration
+	// WriteTimeout 单条消息的写超时
+	WriteTimeout time.Duration
+	// Authorize 在升级前校验请求，返回 *problem.Problem 时按其状态码响应，其他错误返回 401
+	Authorize func(r *http.Request) error
+	// CheckOrigin 校验 Origin，默认只允许同源或不带 Origin 的请求
+	CheckOrigin func(r *http.Request) bool
+}
+
+func (o *Options) setDefaults() {
+	if len(o.Topics) == 0 {
+		o.Topics = []string{"*"}
+	}
+	if o.SendQueue <= 0 {
+		o.SendQueue = 64
+	}
+	if o.MaxMessageSize <= 0 {
+		o.MaxMessageSize = 64 << 10
+	}
+	if o.PingInterval <= 0 {
+		o.PingInterval = 30 * time.Second
+	}
+	if o.WriteTimeout <= 0 {
+		o.WriteTimeout = 10 * time.Second
+	}
+	if o.CheckOrigin == nil {
+		o.CheckOrigin = sameOrigin
+	}
+}
+
+// Request 客户端控制帧
+type Request struct {
+	Action string          `json:"action"` // subscribe、unsubscribe、publish
+	Ref    string          `json:"ref,omitempty"`
+	Topics []string        `json:"topics,omitempty"`
+	Topic  string          `json:"topic,omitempty"`
+	Data   json.RawMessage `json:"data,omitempty"`
+}
+
+// Message 服务端下发的消息
+type Message struct {
+	Type    string    `json:"type"` // event、ack、error
+	Ref     string    `json:"ref,omitempty"`
+	ID      uint64    `json:"id,omitempty"`
+	Topic   string    `json:"topic,omitempty"`
+	Topics  []string  `json:"topics,omitempty"`
+	Data    any       `json:"data,omitempty"`
+	Time    time.Time `json:"time,omitzero"`
+	Message string    `json:"message,omitempty"`
+}
+
+// Hub 管理 WebSocket 连接，将事件总线上的事件按订阅推送给客户端，同时作为升级端点的 http.Handler
+type Hub struct {
+	bus  *eventbus.EventBus
+	opts Options
+
+	mu      sync.Mutex
+	clients map[*client]struct{}
+	closed  bool
+}
+
+// NewHub 创建订阅中心
+func NewHub(bus *eventbus.EventBus, opts Options) *Hub {
+	opts.setDefaults()
+	return &Hub{
+		bus:     bus,
+		opts:    opts,
+		clients: make(map[*client]struct{}),
+	}
+}
+
+// Count 当前连接数
+func (h *Hub) Count() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.clients)
+}
+
+// Close 向所有连接发送关闭帧并断开，之后的升级请求返回 503。
+// 被接管的连接不受 http.Server.Shutdown 管理，需在关闭服务时调用。
+func (h *Hub) Close() {
+	h.mu.Lock()
+	h.closed = true
+	list := make([]*client, 0, len(h.clients))
+	for c := range h.clients {
+		list = append(list, c)
+	}
+	h.mu.Unlock()
+
+	for _, c := range list {
+		c.conn.WriteClose(CloseGoingAway, "server shutting down")
+		c.conn.Close()
+	}
+}
+
+func (h *Hub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.opts.Authorize != nil {
+		if err := h.opts.Authorize(r); err != nil {
+			var p *problem.Problem
+			if !errors.As(err, &p) {
+				p = problem.Unauthorized(err.Error())
+			}
+			problem.Write(w, r, p)
+			return
+		}
+	}
+	if !h.opts.CheckOrigin(r) {
+		problem.Error(w, r, http.StatusForbidden, "origin not allowed")
+		return
+	}
+
+	h.mu.Lock()
+	closed := h.closed
+	h.mu.Unlock()
+	if closed {
+		problem.Error(w, r, http.StatusServiceUnavailable, "server shutting down")
+		return
+	}
+
+	conn, err := Upgrade(w, r, h.opts.MaxMessageSize)
+	if err != nil {
+		return
+	}
+
+	c := &client{
+		hub:     h,
+		conn:    conn,
+		sub:     h.bus.SubscribeTopics(h.opts.SendQueue),
+		replies: make(chan Message, 16),
+		done:    make(chan struct{}),
+	}
+	h.mu.Lock()
+	h.clients[c] = struct{}{}
+	h.mu.Unlock()
+	slog.Debug("websocket: client connected", "remote", conn.RemoteAddr())
+
+	go c.writeLoop()
+	err = c.readLoop()
+
+	close(c.done)
+	c.sub.Unsubscribe()
+	conn.Close()
+	h.mu.Lock()
+	delete(h.clients, c)
+	h.mu.Unlock()
+	slog.Debug("websocket: client disconnected", "remote", conn.RemoteAddr(), "reason", err)
+}
+
+// client 单个连接，读循环在处理请求的 goroutine 中运行，写循环独占发送
+type client struct {
+	hub     *Hub
+	conn    *Conn
+	sub     *eventbus.Subscription
+	replies chan Message
+	done    chan struct{}
+}
+
+func (c *client) readLoop() error {
+	pongWait := 2 * c.hub.opts.PingInterval
+	c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	c.conn.SetPongHandler(func() { c.conn.SetReadDeadline(time.Now().Add(pongWait)) })
+
+	for {
+		typ, data, err := c.conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+		c.conn.SetReadDeadline(time.Now().Add(pongWait))
+
+		if typ != TextMessage {
+			c.conn.WriteClose(CloseUnsupportedData, "text frames only")
+			return &CloseError{Code: CloseUnsupportedData, Text: "text frames only"}
+		}
+		var req Request
+		if err := json.Unmarshal(data, &req); err != nil {
+			c.reply(Message{Type: "error", Message: "invalid control frame: " + err.Error()})
+			continue
+		}
+		c.reply(c.handle(req))
+	}
+}
+
+// handle 处理控制帧并返回应答
+func (c *client) handle(req Request) Message {
+	fail := func(msg string) Message {
+		return Message{Type: "error", Ref: req.Ref, Message: msg}
+	}
+
+	switch req.Action {
+	case "subscribe":
+		for _, t := range req.Topics {
+			if !permitted(c.hub.opts.Topics, t) {
+				return fail("topic not permitted: " + t)
+			}
+		}
+		c.sub.Add(req.Topics...)
+	case "unsubscribe":
+		c.sub.Remove(req.Topics...)
+	case "publish":
+		if req.Topic == "" || strings.Contains(req.Topic, "*") {
+			return fail("publish requires a concrete topic")
+		}
+		if !permitted(c.hub.opts.Commands, req.Topic) {
+			return fail("publish not permitted: " + req.Topic)
+		}
+		ev := c.hub.bus.Emit(req.Topic, req.Data)
+		return Message{Type: "ack", Ref: req.Ref, ID: ev.ID, Topic: ev.Topic}
+	default:
+		return fail("unknown action: " + req.Action)
+	}
+	return Message{Type: "ack", Ref: req.Ref, Topics: c.sub.Topics()}
+}
+
+// reply 将应答放入发送队列，队列满时断开慢消费者
+func (c *client) reply(m Message) {
+	select {
+	case c.replies <- m:
+	default:
+		c.kick()
+	}
+}
+
+// kick 以 1013 关闭发送跟不上的连接，读循环随之退出
+func (c *client) kick() {
+	c.conn.WriteClose(CloseTryAgainLater, "send queue full")
+	c.conn.Close()
+}
+
+func (c *client) writeLoop() {
+	ticker := time.NewTicker(c.hub.opts.PingInterval)
+	defer ticker.Stop()
+
+	for {
+		var m Message
+		select {
+		case <-c.done:
+			return
+		case ev, ok := <-c.sub.C():
+			if !ok {
+				return
+			}
+			m = Message{Type: "event", ID: ev.ID, Topic: ev.Topic, Data: ev.Data, Time: ev.Time}
+		case m = <-c.replies:
+		case <-ticker.C:
+			if err := c.conn.WriteControl(PingMessage, nil); err != nil {
+				c.conn.Close()
+				return
+			}
+			continue
+		}
+
+		data, err := json.Marshal(m)
+		if err != nil {
+			slog.Warn("websocket: marshal message failed", "topic", m.Topic, "error", err)
+			continue
+		}
+		if err := c.conn.WriteMessage(TextMessage, data, time.Now().Add(c.hub.opts.WriteTimeout)); err != nil {
+			c.conn.Close()
+			return
+		}
+		// 订阅缓冲区满时事件总线会丢弃事件，此时断开连接让客户端重新同步
+		if c.sub.Dropped() > 0 {
+			c.kick()
+			return
+		}
+	}
+}
+
+// permitted 主题需落在允许的主题模式内
+func permitted(patterns []string, topic string) bool {
+	for _, p := range patterns {
+		if p == "*" || eventbus.MatchTopic(p, strings.TrimSuffix(topic, "*")) {
+			return true
+		}
+	}
+	return false
+}
+
+// sameOrigin 防止跨站 WebSocket 劫持：浏览器请求的 Origin 需与 Host 一致
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Host, r.Host)
+}
@@ -0,0 +1,226 @@
+package websocket
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"scaffold/pkg/eventbus"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testClient 测试用的 WebSocket 客户端
+type testClient struct {
+	t    *testing.T
+	conn net.Conn
+	br   *bufio.Reader
+}
+
+// dial 连接订阅中心并完成握手
+func dial(t *testing.T, srv *httptest.Server) *testClient {
+	t.Helper()
+	conn, err := net.Dial("tcp", strings.TrimPrefix(srv.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+	if err := req.Write(conn); err != nil {
+		t.Fatal(err)
+	}
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("handshake status = %d", resp.StatusCode)
+	}
+	if got := resp.Header.Get("Sec-WebSocket-Accept"); got != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("Sec-WebSocket-Accept = %q", got)
+	}
+	return &testClient{t: t, conn: conn, br: br}
+}
+
+func (c *testClient) send(req Request) {
+	c.t.Helper()
+	data, _ := json.Marshal(req)
+	if _, err := c.conn.Write(clientFrame(true, TextMessage, data, true)); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+// read 读取下一条消息，跳过 ping；收到关闭帧时返回关闭码
+func (c *testClient) read() (Message, int) {
+	c.t.Helper()
+	for {
+		_, op, payload, err := serverFrame(c.br)
+		if err != nil {
+			c.t.Fatalf("read frame: %v", err)
+		}
+		switch op {
+		case PingMessage:
+			continue
+		case CloseMessage:
+			return Message{}, int(binary.BigEndian.Uint16(payload))
+		}
+		var m Message
+		if err := json.Unmarshal(payload, &m); err != nil {
+			c.t.Fatalf("decode message %q: %v", payload, err)
+		}
+		return m, 0
+	}
+}
+
+func (c *testClient) expect(typ, ref string) Message {
+	c.t.Helper()
+	m, code := c.read()
+	if code != 0 {
+		c.t.Fatalf("connection closed with %d", code)
+	}
+	if m.Type != typ || m.Ref != ref {
+		c.t.Fatalf("message = %+v, want type %q ref %q", m, typ, ref)
+	}
+	return m
+}
+
+func TestHubSubscribe(t *testing.T) {
+	bus := eventbus.New(1)
+	hub := NewHub(bus, Options{Topics: []string{"device.*", "alarm"}})
+	srv := httptest.NewServer(hub)
+	defer srv.Close()
+	defer hub.Close()
+
+	c := dial(t, srv)
+	c.send(Request{Action: "subscribe", Ref: "1", Topics: []string{"device.*"}})
+	if m := c.expect("ack", "1"); len(m.Topics) != 1 || m.Topics[0] != "device.*" {
+		t.Fatalf("ack topics = %v", m.Topics)
+	}
+	c.send(Request{Action: "subscribe", Ref: "2", Topics: []string{"secret"}})
+	c.expect("error", "2")
+
+	bus.Emit("other", 0)
+	bus.Emit("device.a", 1)
+	if m := c.expect("event", ""); m.Topic != "device.a" || m.Data != float64(1) {
+		t.Fatalf("event = %+v", m)
+	}
+
+	c.send(Request{Action: "unsubscribe", Ref: "3", Topics: []string{"device.*"}})
+	if m := c.expect("ack", "3"); len(m.Topics) != 0 {
+		t.Fatalf("topics after unsubscribe = %v", m.Topics)
+	}
+	c.send(Request{Action: "subscribe", Ref: "4", Topics: []string{"alarm"}})
+	c.expect("ack", "4")
+	// 取消订阅的主题不再推送，下一条事件应是 alarm
+	bus.Emit("device.b", 2)
+	bus.Emit("alarm", 3)
+	if m := c.expect("event", ""); m.Topic != "alarm" {
+		t.Fatalf("event = %+v", m)
+	}
+
+	c.send(Request{Action: "nope", Ref: "5"})
+	c.expect("error", "5")
+	if n := hub.Count(); n != 1 {
+		t.Fatalf("Count = %d", n)
+	}
+}
+
+func TestHubPublish(t *testing.T) {
+	tests := []struct {
+		name     string
+		commands []string
+		topic    string
+		ok       bool
+	}{
+		{"permitted", []string{"cmd.*"}, "cmd.reboot", true},
+		{"outside commands", []string{"cmd.*"}, "device.a", false},
+		{"wildcard topic", []string{"cmd.*"}, "cmd.*", false},
+		{"empty topic", []string{"cmd.*"}, "", false},
+		{"publishing disabled", nil, "cmd.reboot", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bus := eventbus.New(1)
+			hub := NewHub(bus, Options{Commands: tt.commands})
+			srv := httptest.NewServer(hub)
+			defer srv.Close()
+			defer hub.Close()
+			sub := bus.SubscribeTopics(4, "*")
+			defer sub.Unsubscribe()
+
+			c := dial(t, srv)
+			c.send(Request{Action: "publish", Ref: "p", Topic: tt.topic, Data: json.RawMessage(`{"force":true}`)})
+			if !tt.ok {
+				c.expect("error", "p")
+				if bus.LastID() != 0 {
+					t.Fatal("rejected command was emitted")
+				}
+				return
+			}
+			m := c.expect("ack", "p")
+			ev := <-sub.C()
+			if m.ID != ev.ID || ev.Topic != tt.topic || string(ev.Data.(json.RawMessage)) != `{"force":true}` {
+				t.Fatalf("ack = %+v, event = %+v", m, ev)
+			}
+		})
+	}
+}
+
+// TestHubKickSlowClient 发送队列溢出时以 1013 断开连接
+func TestHubKickSlowClient(t *testing.T) {
+	bus := eventbus.New(1)
+	hub := NewHub(bus, Options{SendQueue: 1})
+	srv := httptest.NewServer(hub)
+	defer srv.Close()
+	defer hub.Close()
+
+	c := dial(t, srv)
+	c.send(Request{Action: "subscribe", Ref: "1", Topics: []string{"*"}})
+	c.expect("ack", "1")
+
+	// 客户端暂停读取，大事件填满 TCP 缓冲区后写循环阻塞，订阅缓冲区随之溢出
+	payload := strings.Repeat("x", 64<<10)
+	for range 256 {
+		bus.Emit("bulk", payload)
+	}
+	for {
+		_, code := c.read()
+		if code == 0 {
+			continue
+		}
+		if code != CloseTryAgainLater {
+			t.Fatalf("close code = %d, want %d", code, CloseTryAgainLater)
+		}
+		break
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for hub.Count() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("kicked client still registered")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestHubRejectsCrossOrigin(t *testing.T) {
+	hub := NewHub(eventbus.New(1), Options{})
+	defer hub.Close()
+	r := httptest.NewRequest(http.MethodGet, "http://example.com/ws", nil)
+	r.Header.Set("Origin", "https://evil.example.net")
+	rec := httptest.NewRecorder()
+	hub.ServeHTTP(rec, r)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("status = %d", rec.Code)
+	}
+}
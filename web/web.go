@@ -0,0 +1,171 @@
+// Package web 托管前端单页应用，默认使用内嵌的 dist 目录
+package web
+
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"regexp"
+	"scaffold/pkg/common/problem"
+	"strings"
+	"sync"
+)
+
+//go:embed all:dist
+var embedded embed.FS
+
+const indexFile = "index.html"
+
+// hashedRe 匹配带内容哈希的文件名，如 app.3f2a9c1b.js、index-BQv3c1Ad.css
+var hashedRe = regexp.MustCompile(`[.-]([A-Za-z0-9_]{8,})\.[A-Za-z0-9]+$`)
+
+// Handler 前端资源处理器
+type Handler struct {
+	fsys  fs.FS
+	etags sync.Map // name -> etag，仅用于内嵌资源
+	disk  bool
+}
+
+// New 创建前端资源处理器，dir 非空时从磁盘目录读取（开发调试用），否则使用内嵌资源
+func New(dir string) *Handler {
+	if dir != "" {
+		return &Handler{fsys: os.DirFS(dir), disk: true}
+	}
+	sub, _ := fs.Sub(embedded, "dist")
+	return &Handler{fsys: sub}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		writeError(w, r, http.StatusMethodNotAllowed)
+		return
+	}
+
+	name := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+	if name == "" {
+		name = indexFile
+	}
+
+	if !h.isFile(name) {
+		// 带扩展名的资源不存在时返回 404，其余视为前端路由，回退到 index.html
+		if path.Ext(name) != "" {
+			writeError(w, r, http.StatusNotFound)
+			return
+		}
+		name = indexFile
+	}
+
+	h.serveFile(w, r, name)
+}
+
+// writeError 以 problem+json 输出错误，清除已为资源设置的缓存和编码头
+func writeError(w http.ResponseWriter, r *http.Request, status int) {
+	h := w.Header()
+	h.Del("Cache-Control")
+	h.Del("Content-Encoding")
+	problem.Error(w, r, status, "")
+}
+
+// serveFile 输出文件，客户端支持 gzip 且存在 .gz 预压缩文件时优先输出压缩版本
+func (h *Handler) serveFile(w http.ResponseWriter, r *http.Request, name string) {
+	header := w.Header()
+	header.Set("Cache-Control", cacheControl(name))
+	header.Add("Vary", "Accept-Encoding")
+	if ct := mime.TypeByExtension(path.Ext(name)); ct != "" {
+		header.Set("Content-Type", ct)
+	}
+
+	serveName := name
+	if acceptsGzip(r) && h.isFile(name+".gz") {
+		serveName = name + ".gz"
+		header.Set("Content-Encoding", "gzip")
+		if header.Get("Content-Type") == "" {
+			header.Set("Content-Type", "application/octet-stream")
+		}
+	}
+
+	f, err := h.fsys.Open(serveName)
+	if err != nil {
+		writeError(w, r, http.StatusNotFound)
+		return
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError)
+		return
+	}
+	rs, ok := f.(io.ReadSeeker)
+	if !ok {
+		writeError(w, r, http.StatusInternalServerError)
+		return
+	}
+
+	// 内嵌资源没有修改时间，使用内容哈希作为 ETag 以支持协商缓存
+	if !h.disk {
+		if etag := h.etag(serveName, rs); etag != "" {
+			header.Set("ETag", etag)
+		}
+	}
+	http.ServeContent(w, r, name, info.ModTime(), rs)
+}
+
+func (h *Handler) isFile(name string) bool {
+	info, err := fs.Stat(h.fsys, name)
+	return err == nil && !info.IsDir()
+}
+
+func (h *Handler) etag(name string, rs io.ReadSeeker) string {
+	if v, ok := h.etags.Load(name); ok {
+		return v.(string)
+	}
+	sum := sha256.New()
+	if _, err := io.Copy(sum, rs); err != nil {
+		return ""
+	}
+	if _, err := rs.Seek(0, io.SeekStart); err != nil {
+		return ""
+	}
+	etag := `"` + hex.EncodeToString(sum.Sum(nil)[:16]) + `"`
+	h.etags.Store(name, etag)
+	return etag
+}
+
+// cacheControl 带哈希的资源长期缓存，index.html 及其他资源每次协商
+func cacheControl(name string) string {
+	if name != indexFile && isHashed(path.Base(name)) {
+		return "public, max-age=31536000, immutable"
+	}
+	return "no-cache"
+}
+
+// isHashed 哈希段需同时包含字母和数字，避免误判普通文件名
+func isHashed(base string) bool {
+	m := hashedRe.FindStringSubmatch(base)
+	if m == nil {
+		return false
+	}
+	return strings.ContainsAny(m[1], "0123456789") &&
+		strings.ContainsAny(strings.ToLower(m[1]), "abcdefghijklmnopqrstuvwxyz")
+}
+
+func acceptsGzip(r *http.Request) bool {
+	if r.Header.Get("Range") != "" {
+		return false
+	}
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		enc, q, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if strings.EqualFold(strings.TrimSpace(enc), "gzip") && strings.TrimSpace(q) != "q=0" {
+			return true
+		}
+	}
+	return false
+}